@@ -2,19 +2,29 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	cache "github.com/Arkosh744/FirstCache"
 	"github.com/Arkosh744/simpleREST_blog/internal/config"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
 	"github.com/Arkosh744/simpleREST_blog/internal/repository"
 	"github.com/Arkosh744/simpleREST_blog/internal/service"
+	"github.com/Arkosh744/simpleREST_blog/internal/transport/auditlog"
 	grpc_client "github.com/Arkosh744/simpleREST_blog/internal/transport/grpc"
 	"github.com/Arkosh744/simpleREST_blog/internal/transport/rest"
+	"github.com/Arkosh744/simpleREST_blog/pkg/cache"
 	"github.com/Arkosh744/simpleREST_blog/pkg/database"
 	"github.com/Arkosh744/simpleREST_blog/pkg/hash"
+	"github.com/Arkosh744/simpleREST_blog/pkg/storage"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -37,14 +47,15 @@ func Run() error {
 		return err
 	}
 
-	db, err := database.NewPostgresConnection(database.ConnectionInfo{
+	dbInfo := database.ConnectionInfo{
 		Host:     cfg.DBHost,
 		Port:     cfg.DBPort,
 		Username: cfg.DBUser,
 		DBName:   cfg.DBName,
 		SSLMode:  cfg.DBSSLMode,
 		Password: cfg.DBPassword,
-	})
+	}
+	db, err := database.NewPostgresConnection(dbInfo)
 	if err != nil {
 		return err
 	}
@@ -52,19 +63,101 @@ func Run() error {
 
 	hasher := hash.NewSHA1Hasher("Salty Salt")
 
+	postCache, closeCache, err := newPostCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := closeCache(); err != nil {
+			log.Error("failed to close post cache:", err)
+		}
+	}()
+	cachedPosts := service.NewCachedPosts(postCache, cfg.CacheTTL).WithStaleWhileRevalidate(cfg.CacheStaleTTL).
+		WithNotFoundTTL(cfg.CacheNotFoundTTL)
+
+	metrics := prometheus.NewRegistry()
+	metrics.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		service.NewCacheCollector(cachedPosts))
+
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	if cfg.CacheInvalidationChannel != "" {
+		invalidations := repository.NewPostInvalidations(db,
+			database.NewListener(dbInfo, cfg.DBListenMinReconnect, cfg.DBListenMaxReconnect), cfg.CacheInvalidationChannel)
+		changed, err := invalidations.Listen(purgeCtx)
+		if err != nil {
+			return fmt.Errorf("listen for post invalidations: %w", err)
+		}
+		cachedPosts.WithInvalidations(invalidations)
+		go cachedPosts.RunInvalidations(purgeCtx, changed)
+	}
+
 	postsRepo := repository.NewPosts(db)
-	handlerCache := cache.NewCache()
+	commentsRepo := repository.NewComments(db)
+	reactionsRepo := repository.NewReactions(db)
+	bookmarksRepo := repository.NewBookmarks(db)
+	followsRepo := repository.NewFollows(db)
+	mediaRepo := repository.NewMedia(db)
 	usersRepo := repository.NewUsers(db)
 	tokensRepo := repository.NewTokens(db)
 
-	auditClient, err := grpc_client.NewClient(9000)
+	auditSink, err := newAuditSink(cfg)
 	if err != nil {
 		return err
 	}
-	postService := service.NewPosts(postsRepo, handlerCache, auditClient)
-	usersService := service.NewUsers(usersRepo, tokensRepo, auditClient, hasher, []byte(cfg.JWTSecret))
+	if cfg.AuditOverflow != service.AuditOverflowDrop && cfg.AuditOverflow != service.AuditOverflowBlock {
+		return fmt.Errorf("unknown audit overflow policy %q", cfg.AuditOverflow)
+	}
+	auditDispatcher := service.NewAuditDispatcher(auditSink, service.AuditDispatcherConfig{
+		QueueSize:       cfg.AuditQueueSize,
+		BatchSize:       cfg.AuditBatchSize,
+		FlushInterval:   cfg.AuditFlushInterval,
+		Overflow:        cfg.AuditOverflow,
+		MaxRetries:      cfg.AuditMaxRetries,
+		RetryBackoff:    cfg.AuditRetryBackoff,
+		RetryMaxBackoff: cfg.AuditRetryMaxBackoff,
+	})
+	metrics.MustRegister(service.NewAuditCollector(auditDispatcher))
+	auditCtx, stopAudit := context.WithCancel(context.Background())
+	defer stopAudit()
+	go auditDispatcher.Run(auditCtx)
+
+	postService := service.NewPosts(postsRepo, reactionsRepo, bookmarksRepo, cachedPosts, auditDispatcher)
+	commentsService := service.NewComments(commentsRepo, postsRepo, cachedPosts)
+	reactionsService := service.NewReactions(reactionsRepo, cachedPosts)
+	bookmarksService := service.NewBookmarks(bookmarksRepo, reactionsRepo)
+	followsService := service.NewFollows(followsRepo, reactionsRepo, bookmarksRepo)
+	syndicationService := service.NewSyndication(postsRepo, followsRepo, cfg.SiteURL)
+	sitemapService := service.NewSitemap(postsRepo, cfg.SiteURL)
+	mediaStorage, err := newMediaStorage(cfg)
+	if err != nil {
+		return err
+	}
+	mediaService := service.NewMedia(mediaRepo, mediaStorage, cachedPosts, cfg.MediaMaxSize)
+	usersService := service.NewUsers(usersRepo, tokensRepo, auditDispatcher, hasher, []byte(cfg.JWTSecret))
 
-	handler := rest.NewHandler(postService, usersService)
+	handler := rest.NewHandler(postService, usersService).WithPostLimits(domain.PostLimits{
+		TitleMaxLength: cfg.PostTitleMaxLength,
+		BodyMaxLength:  cfg.PostBodyMaxLength,
+	}).WithComments(commentsService).WithReactions(reactionsService).
+		WithBookmarks(bookmarksService).WithFollows(followsService).WithSyndication(syndicationService).
+		WithSitemap(sitemapService).WithMedia(mediaService, cfg.MediaMaxSize).
+		WithCacheAdmin(cachedPosts, cfg.AdminIds).WithMetrics(promhttp.HandlerFor(metrics, promhttp.HandlerOpts{}))
+
+	go postService.RunTrashPurge(purgeCtx, cfg.TrashPurgeInterval, cfg.TrashRetention)
+	go mediaService.RunGC(purgeCtx, cfg.MediaGCInterval, cfg.MediaGCGrace)
+	go mediaService.RunVariants(purgeCtx, cfg.MediaVariantsInterval)
+	auditRelay := service.NewAuditRelay(repository.NewAuditOutbox(db), auditSink, cfg.AuditRelayBatch)
+	var relays sync.WaitGroup
+	relays.Add(2)
+	go func() {
+		defer relays.Done()
+		auditRelay.Run(purgeCtx, cfg.AuditRelayInterval)
+	}()
+	go func() {
+		defer relays.Done()
+		auditRelay.RunPurge(purgeCtx, cfg.AuditOutboxPurgeInterval, cfg.AuditOutboxRetention)
+	}()
 
 	// init & run server
 	srv := &http.Server{
@@ -92,6 +185,17 @@ func Run() error {
 	if err := srv.Shutdown(ctx); err != nil {
 		return err
 	}
+	// no request adds audit events anymore, the queued ones are sent before exiting
+	if err := auditDispatcher.Close(ctx); err != nil {
+		log.Error("failed to flush audit events:", err)
+	}
+	stopAudit()
+	stopPurge()
+	// the relay may be sending a batch through the sink, it is closed once the relay stopped
+	relays.Wait()
+	if err := auditSink.Close(); err != nil {
+		log.Error("failed to close audit sink:", err)
+	}
 	// catching ctx.Done(). timeout of 1 seconds.
 
 	log.Println("timeout of 5 seconds.")
@@ -99,3 +203,115 @@ func Run() error {
 	log.Println("Server exiting")
 	return nil
 }
+
+// newPostCache creates the backend of the post cache selected in the config, along with the function closing
+// its connections.
+func newPostCache(cfg *config.Config) (service.PostCache, func() error, error) {
+	switch cfg.CacheBackend {
+	case "lru":
+		return cache.NewLRU(cfg.CacheSize), func() error { return nil }, nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			client.Close()
+			return nil, nil, fmt.Errorf("connect to redis: %w", err)
+		}
+		return cache.NewRedis(client), client.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown cache backend %q", cfg.CacheBackend)
+	}
+}
+
+// newMediaStorage creates the storage backend of the uploaded media selected in the config.
+func newMediaStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.MediaStorage {
+	case "local":
+		return storage.NewLocal(cfg.MediaLocalDir)
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		}, &http.Client{Timeout: time.Minute})
+	default:
+		return nil, fmt.Errorf("unknown media storage %q", cfg.MediaStorage)
+	}
+}
+
+// newAuditSink creates the audit backends selected in the config. With several of them the audit service,
+// or else the first one, is the primary sink, the others get the copies of the events it took.
+func newAuditSink(cfg *config.Config) (auditlog.Sink, error) {
+	var sinks []auditlog.Sink
+	primary := 0
+	for _, name := range cfg.AuditSinks {
+		name = strings.TrimSpace(name)
+		sink, err := newNamedAuditSink(cfg, name)
+		if err != nil {
+			for _, sink := range sinks {
+				sink.Close()
+			}
+			return nil, err
+		}
+		if name == "grpc" {
+			primary = len(sinks)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	switch len(sinks) {
+	case 0:
+		return nil, errors.New("no audit sink, use \"none\" to disable the audit log")
+	case 1:
+		return sinks[0], nil
+	default:
+		copies := append(append([]auditlog.Sink{}, sinks[:primary]...), sinks[primary+1:]...)
+		return auditlog.Fanout{Primary: sinks[primary], Copies: copies}, nil
+	}
+}
+
+func newNamedAuditSink(cfg *config.Config, name string) (auditlog.Sink, error) {
+	switch name {
+	case "grpc":
+		client, err := grpc_client.NewClient(grpc_client.Config{
+			Addr:       cfg.AuditGRPCAddr,
+			TLS:        cfg.AuditGRPCTLS,
+			CAFile:     cfg.AuditGRPCCAFile,
+			ServerName: cfg.AuditGRPCServerName,
+
+			CallTimeout:         cfg.AuditGRPCTimeout,
+			KeepaliveTime:       cfg.AuditGRPCKeepaliveTime,
+			KeepaliveTimeout:    cfg.AuditGRPCKeepaliveTimeout,
+			ReconnectMaxBackoff: cfg.AuditGRPCReconnectMaxBackoff,
+			BreakerFailures:     cfg.AuditGRPCBreakerFailures,
+			BreakerCooldown:     cfg.AuditGRPCBreakerCooldown,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("connect to audit service: %w", err)
+		}
+		// the events wait in the queue and the outbox while the audit service is down, so it doesn't stop the start
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.CheckHealth(ctx); err != nil {
+			log.WithFields(log.Fields{
+				"addr": cfg.AuditGRPCAddr,
+			}).Warn("audit service is not serving:", err)
+		}
+		return auditlog.NewGRPC(client), nil
+	case "file":
+		return auditlog.NewFile(cfg.AuditFilePath, cfg.AuditFileMaxSize, cfg.AuditFileMaxBackups)
+	case "stdout":
+		return auditlog.NewStdout(), nil
+	case "none":
+		return auditlog.Noop{}, nil
+	default:
+		return nil, fmt.Errorf("unknown audit sink %q", name)
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -13,6 +15,79 @@ type Config struct {
 	DBPassword string `mapstructure:"DB_PASSWORD"`
 	SrvPort    string `mapstructure:"SRV_PORT"`
 	JWTSecret  string `mapstructure:"JWT_SECRET"`
+	SiteURL    string `mapstructure:"SITE_URL"`
+	// AdminIds are the users allowed to use the admin routes, comma separated
+	AdminIds []int64 `mapstructure:"ADMIN_IDS"`
+
+	TrashRetention     time.Duration `mapstructure:"TRASH_RETENTION"`
+	TrashPurgeInterval time.Duration `mapstructure:"TRASH_PURGE_INTERVAL"`
+
+	PostTitleMaxLength int `mapstructure:"POST_TITLE_MAX_LENGTH"`
+	PostBodyMaxLength  int `mapstructure:"POST_BODY_MAX_LENGTH"`
+
+	// MediaStorage is either "local" or "s3"
+	MediaStorage    string        `mapstructure:"MEDIA_STORAGE"`
+	MediaLocalDir   string        `mapstructure:"MEDIA_LOCAL_DIR"`
+	MediaMaxSize    int64         `mapstructure:"MEDIA_MAX_SIZE"`
+	MediaGCInterval time.Duration `mapstructure:"MEDIA_GC_INTERVAL"`
+	MediaGCGrace    time.Duration `mapstructure:"MEDIA_GC_GRACE"`
+	// MediaVariantsInterval is how often the uploads missed by the variants worker are looked for
+	MediaVariantsInterval time.Duration `mapstructure:"MEDIA_VARIANTS_INTERVAL"`
+
+	S3Endpoint  string `mapstructure:"S3_ENDPOINT"`
+	S3Region    string `mapstructure:"S3_REGION"`
+	S3Bucket    string `mapstructure:"S3_BUCKET"`
+	S3AccessKey string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey string `mapstructure:"S3_SECRET_KEY"`
+
+	// CacheBackend is either "lru" for the in-process cache of every replica or "redis" for the shared one
+	CacheBackend string        `mapstructure:"CACHE_BACKEND"`
+	CacheSize    int           `mapstructure:"CACHE_SIZE"`
+	CacheTTL     time.Duration `mapstructure:"CACHE_TTL"`
+	// CacheStaleTTL is how long the expired posts are served while they are read again, zero disables it
+	CacheStaleTTL    time.Duration `mapstructure:"CACHE_STALE_TTL"`
+	CacheNotFoundTTL time.Duration `mapstructure:"CACHE_NOT_FOUND_TTL"`
+	// CacheInvalidationChannel is the Postgres channel the replicas announce the changed posts on, empty disables it
+	CacheInvalidationChannel string        `mapstructure:"CACHE_INVALIDATION_CHANNEL"`
+	DBListenMinReconnect     time.Duration `mapstructure:"DB_LISTEN_MIN_RECONNECT"`
+	DBListenMaxReconnect     time.Duration `mapstructure:"DB_LISTEN_MAX_RECONNECT"`
+
+	RedisAddr     string `mapstructure:"REDIS_ADDR"`
+	RedisPassword string `mapstructure:"REDIS_PASSWORD"`
+	RedisDB       int    `mapstructure:"REDIS_DB"`
+
+	AuditQueueSize     int           `mapstructure:"AUDIT_QUEUE_SIZE"`
+	AuditBatchSize     int           `mapstructure:"AUDIT_BATCH_SIZE"`
+	AuditFlushInterval time.Duration `mapstructure:"AUDIT_FLUSH_INTERVAL"`
+	// AuditOverflow is either "drop" or "block", what happens to the audit events when the queue is full
+	AuditOverflow        string        `mapstructure:"AUDIT_OVERFLOW"`
+	AuditMaxRetries      int           `mapstructure:"AUDIT_MAX_RETRIES"`
+	AuditRetryBackoff    time.Duration `mapstructure:"AUDIT_RETRY_BACKOFF"`
+	AuditRetryMaxBackoff time.Duration `mapstructure:"AUDIT_RETRY_MAX_BACKOFF"`
+	// AuditRelayInterval is how often the outbox is checked for the events of the changes to deliver
+	AuditRelayInterval time.Duration `mapstructure:"AUDIT_RELAY_INTERVAL"`
+	AuditRelayBatch    int           `mapstructure:"AUDIT_RELAY_BATCH"`
+	// AuditOutboxRetention is how long the delivered events are kept in the outbox
+	AuditOutboxRetention     time.Duration `mapstructure:"AUDIT_OUTBOX_RETENTION"`
+	AuditOutboxPurgeInterval time.Duration `mapstructure:"AUDIT_OUTBOX_PURGE_INTERVAL"`
+	// AuditSinks are the audit backends the events are sent to, comma separated: "grpc", "file", "stdout" or "none"
+	AuditSinks          []string `mapstructure:"AUDIT_SINKS"`
+	AuditGRPCAddr       string   `mapstructure:"AUDIT_GRPC_ADDR"`
+	AuditGRPCTLS        bool     `mapstructure:"AUDIT_GRPC_TLS"`
+	AuditGRPCCAFile     string   `mapstructure:"AUDIT_GRPC_CA_FILE"`
+	AuditGRPCServerName string   `mapstructure:"AUDIT_GRPC_SERVER_NAME"`
+	// AuditGRPCTimeout limits every call to the audit service
+	AuditGRPCTimeout             time.Duration `mapstructure:"AUDIT_GRPC_TIMEOUT"`
+	AuditGRPCKeepaliveTime       time.Duration `mapstructure:"AUDIT_GRPC_KEEPALIVE_TIME"`
+	AuditGRPCKeepaliveTimeout    time.Duration `mapstructure:"AUDIT_GRPC_KEEPALIVE_TIMEOUT"`
+	AuditGRPCReconnectMaxBackoff time.Duration `mapstructure:"AUDIT_GRPC_RECONNECT_MAX_BACKOFF"`
+	// AuditGRPCBreakerFailures calls failed in a row skip the calls for AuditGRPCBreakerCooldown, zero disables it
+	AuditGRPCBreakerFailures int           `mapstructure:"AUDIT_GRPC_BREAKER_FAILURES"`
+	AuditGRPCBreakerCooldown time.Duration `mapstructure:"AUDIT_GRPC_BREAKER_COOLDOWN"`
+	AuditFilePath            string        `mapstructure:"AUDIT_FILE_PATH"`
+	// AuditFileMaxSize is the size in bytes the audit file is rotated at, zero never rotates it
+	AuditFileMaxSize    int64 `mapstructure:"AUDIT_FILE_MAX_SIZE"`
+	AuditFileMaxBackups int   `mapstructure:"AUDIT_FILE_MAX_BACKUPS"`
 }
 
 func New(folder string) (*Config, error) {
@@ -21,6 +96,48 @@ func New(folder string) (*Config, error) {
 	viper.SetConfigType("env")
 	viper.AddConfigPath(folder)
 
+	viper.SetDefault("SITE_URL", "http://localhost:8080")
+	viper.SetDefault("TRASH_RETENTION", 30*24*time.Hour)
+	viper.SetDefault("TRASH_PURGE_INTERVAL", time.Hour)
+	viper.SetDefault("POST_TITLE_MAX_LENGTH", 255)
+	viper.SetDefault("POST_BODY_MAX_LENGTH", 100000)
+	viper.SetDefault("MEDIA_STORAGE", "local")
+	viper.SetDefault("MEDIA_LOCAL_DIR", "media")
+	viper.SetDefault("MEDIA_MAX_SIZE", 10<<20)
+	viper.SetDefault("MEDIA_GC_INTERVAL", time.Hour)
+	viper.SetDefault("MEDIA_GC_GRACE", 24*time.Hour)
+	viper.SetDefault("MEDIA_VARIANTS_INTERVAL", time.Minute)
+	viper.SetDefault("CACHE_BACKEND", "lru")
+	viper.SetDefault("CACHE_SIZE", 10000)
+	viper.SetDefault("CACHE_TTL", 6*time.Minute)
+	viper.SetDefault("CACHE_NOT_FOUND_TTL", 5*time.Second)
+	viper.SetDefault("CACHE_INVALIDATION_CHANNEL", "post_invalidations")
+	viper.SetDefault("DB_LISTEN_MIN_RECONNECT", time.Second)
+	viper.SetDefault("DB_LISTEN_MAX_RECONNECT", time.Minute)
+	viper.SetDefault("AUDIT_QUEUE_SIZE", 10000)
+	viper.SetDefault("AUDIT_BATCH_SIZE", 100)
+	viper.SetDefault("AUDIT_FLUSH_INTERVAL", time.Second)
+	viper.SetDefault("AUDIT_OVERFLOW", "drop")
+	viper.SetDefault("AUDIT_MAX_RETRIES", 5)
+	viper.SetDefault("AUDIT_RETRY_BACKOFF", 100*time.Millisecond)
+	viper.SetDefault("AUDIT_RETRY_MAX_BACKOFF", 10*time.Second)
+	viper.SetDefault("AUDIT_RELAY_INTERVAL", time.Second)
+	viper.SetDefault("AUDIT_RELAY_BATCH", 20)
+	viper.SetDefault("AUDIT_OUTBOX_RETENTION", 7*24*time.Hour)
+	viper.SetDefault("AUDIT_OUTBOX_PURGE_INTERVAL", time.Hour)
+	viper.SetDefault("AUDIT_SINKS", "grpc")
+	viper.SetDefault("AUDIT_GRPC_ADDR", "localhost:9000")
+	viper.SetDefault("AUDIT_GRPC_TIMEOUT", 3*time.Second)
+	viper.SetDefault("AUDIT_GRPC_KEEPALIVE_TIME", 5*time.Minute)
+	viper.SetDefault("AUDIT_GRPC_KEEPALIVE_TIMEOUT", 20*time.Second)
+	viper.SetDefault("AUDIT_GRPC_RECONNECT_MAX_BACKOFF", 30*time.Second)
+	viper.SetDefault("AUDIT_GRPC_BREAKER_FAILURES", 5)
+	viper.SetDefault("AUDIT_GRPC_BREAKER_COOLDOWN", 30*time.Second)
+	viper.SetDefault("AUDIT_FILE_PATH", "audit/audit.jsonl")
+	viper.SetDefault("AUDIT_FILE_MAX_SIZE", 100<<20)
+	viper.SetDefault("AUDIT_FILE_MAX_BACKUPS", 10)
+	viper.SetDefault("REDIS_ADDR", "localhost:6379")
+
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// AuditEvent is an audit log entry written to the outbox together with the change it describes.
+// Key is unique for the event, the audit service drops the events it already got with the same key.
+type AuditEvent struct {
+	Id        int64
+	Key       string
+	Entity    string
+	Action    string
+	EntityId  int64
+	UserId    int64
+	Timestamp time.Time
+}
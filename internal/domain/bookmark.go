@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultReadingList keeps the bookmarks saved without a list name.
+const DefaultReadingList = "default"
+
+const readingListMaxLength = 64
+
+type Bookmark struct {
+	List      string    `json:"list"`
+	CreatedAt time.Time `json:"createdAt"`
+	Post      Post      `json:"post"`
+}
+
+// BookmarkPage is a page of bookmarks of a reading list, newest first.
+type BookmarkPage struct {
+	Bookmarks  []Bookmark `json:"bookmarks"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+type ReadingList struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// ReadingListName normalizes the name of the reading list, an empty name means the default list.
+func ReadingListName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return DefaultReadingList, nil
+	}
+	if utf8.RuneCountInString(name) > readingListMaxLength {
+		return "", ErrInvalidReadingList
+	}
+
+	return name, nil
+}
@@ -0,0 +1,9 @@
+package domain
+
+// CacheStats shows how well the post cache of the replica works, Hits and Misses are counted since it started.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Size      int64  `json:"size"`
+}
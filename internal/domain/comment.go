@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+type Comment struct {
+	Id        int64     `json:"id"`
+	PostId    int64     `json:"postId"`
+	ParentId  *int64    `json:"parentId,omitempty"`
+	AuthorId  int64     `json:"authorId"`
+	Body      string    `json:"body"`
+	Deleted   bool      `json:"deleted,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Replies   []Comment `json:"replies,omitempty"`
+}
+
+type CommentInput struct {
+	Body     string `json:"body" validate:"required"`
+	ParentId *int64 `json:"parentId"`
+}
+
+func (i CommentInput) Validate() error {
+	return validate.Struct(i)
+}
+
+type UpdateComment struct {
+	Body string `json:"body" validate:"required"`
+}
+
+func (i UpdateComment) Validate() error {
+	return validate.Struct(i)
+}
+
+// CommentPage is a page of root comments with all their replies.
+type CommentPage struct {
+	Comments   []Comment `json:"comments"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+}
@@ -1,10 +1,46 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 var (
 	ErrPostNotFound        = errors.New("post not found")
 	ErrRefreshTokenExpired = errors.New("refresh token expired")
 	ErrInvalidInput        = errors.New("invalid input body")
 	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrCommentNotFound     = errors.New("comment not found")
+	ErrForbidden           = errors.New("forbidden")
+	ErrInvalidReaction     = errors.New("invalid reaction kind")
+	ErrInvalidReadingList  = errors.New("invalid reading list name")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrFollowSelf          = errors.New("users can not follow themselves")
+	ErrSitemapNotFound     = errors.New("sitemap not found")
+	ErrMediaNotFound       = errors.New("media not found")
+	ErrMediaTooLarge       = errors.New("media is too large")
+	ErrUnsupportedMedia    = errors.New("unsupported media type")
+	ErrVersionMismatch     = errors.New("post has been modified since it was read")
+	ErrCacheKeyNotFound    = errors.New("key is not cached")
+	ErrAuditQueueFull      = errors.New("audit queue is full")
+	ErrAuditClosed         = errors.New("audit dispatcher is closed")
 )
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of the input.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+" "+f.Message)
+	}
+
+	return "invalid input: " + strings.Join(msgs, "; ")
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// Profile is the public view of a user, Following tells whether the calling user follows them.
+type Profile struct {
+	Id             int64     `json:"id"`
+	Name           string    `json:"name"`
+	RegisteredAt   time.Time `json:"registered_at"`
+	FollowersCount int       `json:"followersCount"`
+	FollowingCount int       `json:"followingCount"`
+	Following      bool      `json:"following,omitempty"`
+}
+
+// FeedPage is a page of posts of the followed authors, newest first.
+type FeedPage struct {
+	Posts      []Post `json:"posts"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// FeedEntry is a published post with the name of its author for the syndication feeds.
+type FeedEntry struct {
+	Post
+	AuthorName string
+}
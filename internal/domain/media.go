@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MediaTypes are the sniffed content types accepted for uploads.
+var MediaTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// MediaSizes are the widths the resized JPEG variants of the uploaded images are generated in.
+var MediaSizes = []MediaSize{
+	{Name: "thumbnail", Width: 160},
+	{Name: "medium", Width: 640},
+	{Name: "large", Width: 1280},
+}
+
+// MediaVariantTypes maps the content types of the variants to their extensions.
+var MediaVariantTypes = map[string]string{
+	"image/jpeg": "jpg",
+}
+
+type MediaSize struct {
+	Name  string
+	Width int
+}
+
+// Media is an uploaded file, the content is stored once per Hash however many times it was uploaded.
+// Variants stay empty until the resized images are generated in the background.
+type Media struct {
+	Id        int64          `json:"id"`
+	OwnerId   int64          `json:"ownerId"`
+	Hash      string         `json:"hash"`
+	MimeType  string         `json:"mimeType"`
+	Size      int64          `json:"size"`
+	URL       string         `json:"url"`
+	Variants  []MediaVariant `json:"variants,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// MediaVariant is a resized copy of an uploaded image, the variants ordered by Width make a srcset.
+type MediaVariant struct {
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Hash     string `json:"-"`
+	Size     int64  `json:"size"`
+	URL      string `json:"url"`
+}
+
+// MediaPath is the address the content of the media is served from.
+func MediaPath(id int64) string {
+	return "/media/" + strconv.FormatInt(id, 10)
+}
+
+// MediaVariantPath is the address the variant of the media is served from, e.g. /media/7/medium.jpg.
+func MediaVariantPath(id int64, name, mimeType string) string {
+	return MediaPath(id) + "/" + name + "." + MediaVariantTypes[mimeType]
+}
+
+// ParseMediaVariant splits the last element of the variant path into the variant name and content type.
+func ParseMediaVariant(file string) (name, mimeType string, ok bool) {
+	for t, ext := range MediaVariantTypes {
+		if name := strings.TrimSuffix(file, "."+ext); name != file && name != "" {
+			return name, t, true
+		}
+	}
+
+	return "", "", false
+}
+
+func ValidMediaType(mimeType string) bool {
+	for _, t := range MediaTypes {
+		if t == mimeType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,16 +1,46 @@
 package domain
 
 import (
+	"fmt"
 	"time"
+	"unicode/utf8"
 )
 
+// Post is shared by all readers except MyReactions and Bookmarked, those belong to the user who asked for it.
 type Post struct {
-	Id        int64     `json:"id"`
-	Title     string    `json:"title"`
-	Body      string    `json:"body"`
-	AuthorId  int64     `json:"AuthorId"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	Id    int64  `json:"id"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	PostContent
+	Slug          string         `json:"slug,omitempty"`
+	AuthorId      int64          `json:"AuthorId"`
+	CommentsCount int            `json:"commentsCount,omitempty"`
+	Reactions     map[string]int `json:"reactions,omitempty"`
+	MyReactions   []string       `json:"myReactions,omitempty"`
+	Bookmarked    bool           `json:"bookmarked,omitempty"`
+	Media         []Media        `json:"media,omitempty"`
+	Version       int64          `json:"version,omitempty"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+	DeletedAt     *time.Time     `json:"deletedAt,omitempty"`
+}
+
+// PostContent is derived from the markdown body when the post is written.
+type PostContent struct {
+	BodyHTML    string `json:"bodyHTML,omitempty"`
+	Excerpt     string `json:"excerpt,omitempty"`
+	WordCount   int    `json:"wordCount,omitempty"`
+	ReadingTime int    `json:"readingTime,omitempty"`
+}
+
+// Permalink is the stable address of the post built from its current slug.
+func (p Post) Permalink() string {
+	return "/post/by-slug/" + p.Slug
+}
+
+// PublicLink is the address of the post for the readers without an account, the feeds and the sitemap link to it.
+func (p Post) PublicLink() string {
+	return "/posts/" + p.Slug
 }
 
 type PostQuery struct {
@@ -18,10 +48,52 @@ type PostQuery struct {
 	Body  string `json:"body"`
 }
 
+// UpdatePost changes the fields of a post that are not nil, an empty Body clears the body.
 type UpdatePost struct {
-	Id    int64  `json:"id"`
-	Title string `json:"title"`
-	Body  string `json:"body"`
+	Id    int64   `json:"id"`
+	Title *string `json:"title"`
+	Body  *string `json:"body"`
+
+	// Version the update is based on, the update fails with ErrVersionMismatch once the post has moved on.
+	// Zero updates any version.
+	Version int64       `json:"-"`
+	Slug    string      `json:"-"`
+	Content PostContent `json:"-"`
+}
+
+// PostInvalidation is the ids of the posts changed by another replica of the app.
+// Reset means some of the changes may have been missed, so none of the cached posts can be trusted.
+type PostInvalidation struct {
+	Ids   []int64
+	Reset bool
+}
+
+// PostLimits bounds the length of the post fields in characters.
+type PostLimits struct {
+	TitleMaxLength int
+	BodyMaxLength  int
+}
+
+// Check validates the maximum lengths of the post fields.
+func (l PostLimits) Check(title, body string) error {
+	var fields []FieldError
+	if n := utf8.RuneCountInString(title); l.TitleMaxLength > 0 && n > l.TitleMaxLength {
+		fields = append(fields, FieldError{
+			Field:   "title",
+			Message: fmt.Sprintf("must be at most %d characters, got %d", l.TitleMaxLength, n),
+		})
+	}
+	if n := utf8.RuneCountInString(body); l.BodyMaxLength > 0 && n > l.BodyMaxLength {
+		fields = append(fields, FieldError{
+			Field:   "body",
+			Message: fmt.Sprintf("must be at most %d characters, got %d", l.BodyMaxLength, n),
+		})
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
 }
 
 type PostError struct {
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/lib/pq"
+	"time"
+)
+
+type Bookmarks struct {
+	db *sql.DB
+}
+
+func NewBookmarks(db *sql.DB) *Bookmarks {
+	return &Bookmarks{db}
+}
+
+// Add saves the post to the reading list of the user, saving it again changes nothing.
+func (r *Bookmarks) Add(ctx context.Context, userId, postId int64, list string) error {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM posts WHERE id=$1 AND deleted_at IS NULL)", postId).
+		Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return domain.ErrPostNotFound
+	}
+
+	_, err := r.db.ExecContext(ctx, "INSERT INTO bookmarks (user_id, list, post_id, createdAt) values ($1, $2, $3, $4) "+
+		"ON CONFLICT DO NOTHING", userId, list, postId, time.Now())
+
+	return err
+}
+
+// Remove takes the post out of the reading list of the user, removing a missing one changes nothing.
+func (r *Bookmarks) Remove(ctx context.Context, userId, postId int64, list string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM bookmarks WHERE user_id=$1 AND list=$2 AND post_id=$3", userId, list, postId)
+
+	return err
+}
+
+// List returns up to limit bookmarks of the reading list saved before the given one, newest first.
+// A zero before starts from the newest bookmark.
+func (r *Bookmarks) List(ctx context.Context, userId int64, list string, before time.Time, beforePostId int64,
+	limit int) ([]domain.Bookmark, error) {
+	var after any
+	if !before.IsZero() {
+		after = before
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+postColumns+", b.list, b.bookmarked_at FROM posts "+
+		"JOIN (SELECT list, createdAt AS bookmarked_at, post_id FROM bookmarks WHERE user_id=$1 AND list=$2) b ON b.post_id = posts.id "+
+		"WHERE posts.deleted_at IS NULL AND ($3::timestamp IS NULL OR (b.bookmarked_at, b.post_id) < ($3, $4)) "+
+		"ORDER BY b.bookmarked_at DESC, b.post_id DESC LIMIT $5", userId, list, after, beforePostId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookmarks := make([]domain.Bookmark, 0)
+	for rows.Next() {
+		var bookmark domain.Bookmark
+		if err := scanPost(rows, &bookmark.Post, &bookmark.List, &bookmark.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		bookmarks = append(bookmarks, bookmark)
+	}
+
+	return bookmarks, rows.Err()
+}
+
+// ListReadingLists returns the reading lists of the user with the number of saved posts in each.
+func (r *Bookmarks) ListReadingLists(ctx context.Context, userId int64) ([]domain.ReadingList, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT list, count(*) FROM bookmarks "+
+		"JOIN posts ON posts.id = bookmarks.post_id AND posts.deleted_at IS NULL "+
+		"WHERE user_id=$1 GROUP BY list ORDER BY list", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := make([]domain.ReadingList, 0)
+	for rows.Next() {
+		var list domain.ReadingList
+		if err := rows.Scan(&list.Name, &list.Count); err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, list)
+	}
+
+	return lists, rows.Err()
+}
+
+// Bookmarked returns which of the given posts the user saved to any of the reading lists.
+func (r *Bookmarks) Bookmarked(ctx context.Context, userId int64, postIds []int64) (map[int64]bool, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT DISTINCT post_id FROM bookmarks WHERE user_id=$1 AND post_id = ANY($2)",
+		userId, pq.Array(postIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookmarked := make(map[int64]bool)
+	for rows.Next() {
+		var postId int64
+		if err := rows.Scan(&postId); err != nil {
+			return nil, err
+		}
+
+		bookmarked[postId] = true
+	}
+
+	return bookmarked, rows.Err()
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/lib/pq"
+	"time"
+)
+
+const commentColumns = "id, post_id, parent_id, author_id, body, deleted_at IS NOT NULL, createdAt, updatedAt"
+
+type Comments struct {
+	db *sql.DB
+}
+
+func NewComments(db *sql.DB) *Comments {
+	return &Comments{db}
+}
+
+// Create stores the comment, bumps the comments counter of its post and writes the audit event along with them.
+// A reply must belong to the same post as its parent.
+func (r *Comments) Create(ctx context.Context, comment domain.Comment, event domain.AuditEvent) (domain.Comment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return comment, err
+	}
+	defer tx.Rollback()
+
+	if comment.ParentId != nil {
+		var exists bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM comments WHERE id=$1 AND post_id=$2 AND deleted_at IS NULL)",
+			*comment.ParentId, comment.PostId).Scan(&exists)
+		if err != nil {
+			return comment, err
+		}
+		if !exists {
+			return comment, domain.ErrCommentNotFound
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, "UPDATE posts SET comments_count = comments_count + 1 WHERE id=$1 AND deleted_at IS NULL",
+		comment.PostId)
+	if err != nil {
+		return comment, err
+	}
+	if err := checkAffected(res, domain.ErrPostNotFound); err != nil {
+		return comment, err
+	}
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO comments (post_id, parent_id, author_id, body, createdAt, updatedAt) "+
+		"values ($1, $2, $3, $4, $5, $5) returning id",
+		comment.PostId, comment.ParentId, comment.AuthorId, comment.Body, comment.CreatedAt).Scan(&comment.Id)
+	if err != nil {
+		return comment, err
+	}
+	if err := insertAuditEvent(ctx, tx, event); err != nil {
+		return comment, err
+	}
+
+	return comment, tx.Commit()
+}
+
+func (r *Comments) GetById(ctx context.Context, id int64) (domain.Comment, error) {
+	var comment domain.Comment
+	err := scanComment(r.db.QueryRowContext(ctx, "SELECT "+commentColumns+" FROM comments WHERE id=$1 AND deleted_at IS NULL", id),
+		&comment)
+	if err == sql.ErrNoRows {
+		return comment, domain.ErrCommentNotFound
+	}
+
+	return comment, err
+}
+
+// ListRoots returns up to limit root comments of the post created after the given one.
+func (r *Comments) ListRoots(ctx context.Context, postId int64, afterId int64, limit int) ([]domain.Comment, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+commentColumns+" FROM comments "+
+		"WHERE post_id=$1 AND parent_id IS NULL AND id > $2 ORDER BY id LIMIT $3", postId, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanComments(rows)
+}
+
+// ListReplies returns every reply under the given comments, at any depth, oldest first.
+func (r *Comments) ListReplies(ctx context.Context, rootIds []int64) ([]domain.Comment, error) {
+	rows, err := r.db.QueryContext(ctx, "WITH RECURSIVE thread AS ("+
+		"SELECT * FROM comments WHERE parent_id = ANY($1) "+
+		"UNION ALL SELECT c.* FROM comments c JOIN thread t ON c.parent_id = t.id"+
+		") SELECT "+commentColumns+" FROM thread ORDER BY id", pq.Array(rootIds))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanComments(rows)
+}
+
+func (r *Comments) Update(ctx context.Context, id int64, body string) (domain.Comment, error) {
+	var comment domain.Comment
+	err := scanComment(r.db.QueryRowContext(ctx, "UPDATE comments SET body=$1, updatedAt=$2 WHERE id=$3 AND deleted_at IS NULL "+
+		"RETURNING "+commentColumns, body, time.Now(), id), &comment)
+	if err == sql.ErrNoRows {
+		return comment, domain.ErrCommentNotFound
+	}
+
+	return comment, err
+}
+
+// Delete hides the comment body but keeps the comment, so the replies stay in the thread.
+// The audit event is written along with it.
+func (r *Comments) Delete(ctx context.Context, id int64, event domain.AuditEvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var postId int64
+	err = tx.QueryRowContext(ctx, "UPDATE comments SET body='', deleted_at=$1 WHERE id=$2 AND deleted_at IS NULL RETURNING post_id",
+		time.Now(), id).Scan(&postId)
+	if err == sql.ErrNoRows {
+		return domain.ErrCommentNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE posts SET comments_count = comments_count - 1 WHERE id=$1", postId); err != nil {
+		return err
+	}
+	if err := insertAuditEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanComment(row scanner, comment *domain.Comment) error {
+	var parentId sql.NullInt64
+	if err := row.Scan(&comment.Id, &comment.PostId, &parentId, &comment.AuthorId, &comment.Body, &comment.Deleted,
+		&comment.CreatedAt, &comment.UpdatedAt); err != nil {
+		return err
+	}
+
+	if parentId.Valid {
+		comment.ParentId = &parentId.Int64
+	}
+	return nil
+}
+
+func scanComments(rows *sql.Rows) ([]domain.Comment, error) {
+	defer rows.Close()
+
+	comments := make([]domain.Comment, 0)
+	for rows.Next() {
+		var comment domain.Comment
+		if err := scanComment(rows, &comment); err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
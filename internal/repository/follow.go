@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"time"
+)
+
+type Follows struct {
+	db *sql.DB
+}
+
+func NewFollows(db *sql.DB) *Follows {
+	return &Follows{db}
+}
+
+// Follow subscribes the follower to the posts of the author, following again changes nothing.
+func (r *Follows) Follow(ctx context.Context, followerId, authorId int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE id=$1)", authorId).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return domain.ErrUserNotFound
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO follows (follower_id, author_id, createdAt) values ($1, $2, $3) ON CONFLICT DO NOTHING",
+		followerId, authorId, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return r.commitCounts(ctx, tx, res, followerId, authorId, 1)
+}
+
+// Unfollow cancels the subscription, unfollowing a user that is not followed changes nothing.
+func (r *Follows) Unfollow(ctx context.Context, followerId, authorId int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM follows WHERE follower_id=$1 AND author_id=$2", followerId, authorId)
+	if err != nil {
+		return err
+	}
+
+	return r.commitCounts(ctx, tx, res, followerId, authorId, -1)
+}
+
+// commitCounts moves the follow counters of both users when the follow was actually changed and commits.
+func (r *Follows) commitCounts(ctx context.Context, tx *sql.Tx, res sql.Result, followerId, authorId int64, delta int) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected > 0 {
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET following_count = following_count + $1 WHERE id=$2",
+			delta, followerId); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET followers_count = followers_count + $1 WHERE id=$2",
+			delta, authorId); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetProfile returns the user with the follow counters, as seen by the viewer.
+func (r *Follows) GetProfile(ctx context.Context, id int64, viewerId int64) (domain.Profile, error) {
+	var profile domain.Profile
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, registered_at, followers_count, following_count, "+
+		"EXISTS(SELECT 1 FROM follows WHERE follower_id=$2 AND author_id=users.id) FROM users WHERE id=$1", id, viewerId).
+		Scan(&profile.Id, &profile.Name, &profile.RegisteredAt, &profile.FollowersCount, &profile.FollowingCount, &profile.Following)
+	if err == sql.ErrNoRows {
+		return profile, domain.ErrUserNotFound
+	}
+
+	return profile, err
+}
+
+// Feed returns up to limit posts of the authors the user follows published before the given one, newest first.
+// A zero before starts from the newest post.
+//
+// Every followed author gives at most limit of their newest posts through posts_feed_idx, and only these
+// are merged and cut to limit, so a page costs the same however many posts the authors have.
+func (r *Follows) Feed(ctx context.Context, userId int64, before time.Time, beforeId int64, limit int) ([]domain.Post, error) {
+	var after any
+	if !before.IsZero() {
+		after = before
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+postColumns+" FROM ("+
+		"SELECT p.* FROM follows f JOIN LATERAL ("+
+		"SELECT "+postColumns+" FROM posts "+
+		"WHERE author_id=f.author_id AND deleted_at IS NULL "+
+		"AND ($2::timestamp IS NULL OR (createdAt, id) < ($2, $3)) "+
+		"ORDER BY createdAt DESC, id DESC LIMIT $4) p ON true "+
+		"WHERE f.follower_id=$1) feed "+
+		"ORDER BY createdAt DESC, id DESC LIMIT $4", userId, after, beforeId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]domain.Post, 0)
+	for rows.Next() {
+		var post domain.Post
+		if err := scanPost(rows, &post); err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// notifyBatch keeps the payloads of the notifications under the 8000 bytes allowed by Postgres.
+	notifyBatch = 256
+	// listenerPing is how long the listener waits for a notification before it checks its connection.
+	listenerPing = 90 * time.Second
+	// resetPayload is notified instead of the ids when every post should be forgotten.
+	resetPayload = "reset"
+)
+
+// PostInvalidations broadcasts the ids of the changed posts to every replica of the app with NOTIFY.
+type PostInvalidations struct {
+	db       *sql.DB
+	listener *pq.Listener
+	channel  string
+}
+
+func NewPostInvalidations(db *sql.DB, listener *pq.Listener, channel string) *PostInvalidations {
+	return &PostInvalidations{
+		db:       db,
+		listener: listener,
+		channel:  channel,
+	}
+}
+
+// Publish notifies the channel with the comma separated ids, this replica gets them back too.
+func (r *PostInvalidations) Publish(ctx context.Context, ids ...int64) error {
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > notifyBatch {
+			batch = batch[:notifyBatch]
+		}
+		ids = ids[len(batch):]
+
+		payload := make([]string, len(batch))
+		for i, id := range batch {
+			payload[i] = strconv.FormatInt(id, 10)
+		}
+		if _, err := r.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", r.channel, strings.Join(payload, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishReset notifies the channel that every post should be forgotten.
+func (r *PostInvalidations) PublishReset(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", r.channel, resetPayload)
+	return err
+}
+
+// Listen subscribes to the channel and delivers its notifications until ctx is done, then closes the listener.
+// A reset is delivered when one was published and once the listener has reconnected, the notifications sent
+// meanwhile are lost.
+func (r *PostInvalidations) Listen(ctx context.Context) (<-chan domain.PostInvalidation, error) {
+	if err := r.listener.Listen(r.channel); err != nil {
+		return nil, err
+	}
+
+	invalidations := make(chan domain.PostInvalidation)
+	go func() {
+		defer close(invalidations)
+		defer r.listener.Close()
+
+		for {
+			var invalidation domain.PostInvalidation
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(listenerPing):
+				go r.listener.Ping()
+				continue
+			case notification := <-r.listener.Notify:
+				if notification == nil || notification.Extra == resetPayload {
+					invalidation.Reset = true
+					break
+				}
+				ids, err := parseIds(notification.Extra)
+				if err != nil {
+					logrus.WithFields(logrus.Fields{
+						"method": "PostInvalidations.Listen",
+					}).Error("failed to parse notification:", err)
+					continue
+				}
+				invalidation.Ids = ids
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case invalidations <- invalidation:
+			}
+		}
+	}()
+	return invalidations, nil
+}
+
+func parseIds(payload string) ([]int64, error) {
+	fields := strings.Split(payload, ",")
+	ids := make([]int64, len(fields))
+	for i, field := range fields {
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
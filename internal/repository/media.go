@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/lib/pq"
+	"time"
+)
+
+const (
+	mediaColumns   = "id, owner_id, hash, mime_type, size, createdAt"
+	variantColumns = "media_id, name, mime_type, width, height, hash, size"
+)
+
+type Media struct {
+	db *sql.DB
+}
+
+func NewMedia(db *sql.DB) *Media {
+	return &Media{db}
+}
+
+// Create records the upload and calls store to save its content while holding the lock of the hash,
+// so DeleteOrphans can not remove the content in between.
+func (r *Media) Create(ctx context.Context, media domain.Media, store func() error) (domain.Media, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return media, err
+	}
+	defer tx.Rollback()
+
+	if err := lockHash(ctx, tx, media.Hash); err != nil {
+		return media, err
+	}
+	if err := store(); err != nil {
+		return media, err
+	}
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO media (owner_id, hash, mime_type, size, createdAt) values ($1, $2, $3, $4, $5) returning id",
+		media.OwnerId, media.Hash, media.MimeType, media.Size, media.CreatedAt).Scan(&media.Id)
+	if err != nil {
+		return media, err
+	}
+	media.URL = domain.MediaPath(media.Id)
+
+	return media, tx.Commit()
+}
+
+func (r *Media) GetById(ctx context.Context, id int64) (domain.Media, error) {
+	var media domain.Media
+	err := scanMedia(r.db.QueryRowContext(ctx, "SELECT "+mediaColumns+" FROM media WHERE id=$1", id), &media)
+	if err == sql.ErrNoRows {
+		return media, domain.ErrMediaNotFound
+	}
+
+	return media, err
+}
+
+// Attach references the media of the user from the post of the user, attaching it again changes nothing.
+func (r *Media) Attach(ctx context.Context, postId, mediaId, userId int64) error {
+	if err := r.checkOwners(ctx, postId, mediaId, userId); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, "INSERT INTO post_media (post_id, media_id, createdAt) values ($1, $2, $3) ON CONFLICT DO NOTHING",
+		postId, mediaId, time.Now())
+
+	return err
+}
+
+// Detach drops the reference to the media from the post, detaching a missing one changes nothing.
+func (r *Media) Detach(ctx context.Context, postId, mediaId, userId int64) error {
+	if err := r.checkOwners(ctx, postId, mediaId, userId); err != nil {
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM post_media WHERE post_id=$1 AND media_id=$2", postId, mediaId)
+
+	return err
+}
+
+// DeleteOrphans removes the uploads created before the given time that no post references, with their variants,
+// and calls remove for every hash whose content is not used by any upload or variant anymore.
+// It returns the number of removed uploads.
+func (r *Media) DeleteOrphans(ctx context.Context, before time.Time, remove func(hash string) error) (int64, error) {
+	rows, err := r.db.QueryContext(ctx, "WITH deleted AS (DELETE FROM media WHERE createdAt < $1 "+
+		"AND NOT EXISTS (SELECT 1 FROM post_media WHERE post_media.media_id = media.id) RETURNING id, hash), "+
+		"variants AS (DELETE FROM media_variants WHERE media_id IN (SELECT id FROM deleted) RETURNING hash) "+
+		"SELECT hash, true FROM deleted UNION ALL SELECT hash, false FROM variants", before)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var deleted int64
+	hashes := make(map[string]struct{})
+	for rows.Next() {
+		var hash string
+		var upload bool
+		if err := rows.Scan(&hash, &upload); err != nil {
+			return deleted, err
+		}
+
+		hashes[hash] = struct{}{}
+		if upload {
+			deleted++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return deleted, err
+	}
+
+	for hash := range hashes {
+		if err := r.removeContent(ctx, hash, remove); err != nil {
+			return deleted, err
+		}
+	}
+
+	return deleted, nil
+}
+
+// ListUnprocessed returns up to limit uploads after the given one, oldest first, whose variants have not been generated yet.
+func (r *Media) ListUnprocessed(ctx context.Context, afterId int64, limit int) ([]domain.Media, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+mediaColumns+" FROM media WHERE processed_at IS NULL AND id > $1 ORDER BY id LIMIT $2",
+		afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	uploads := make([]domain.Media, 0)
+	for rows.Next() {
+		var media domain.Media
+		if err := scanMedia(rows, &media); err != nil {
+			return nil, err
+		}
+
+		uploads = append(uploads, media)
+	}
+
+	return uploads, rows.Err()
+}
+
+// SaveVariants records the variants of the upload and marks it as processed, even when there are none.
+// store is called for every variant while holding the lock of its hash, see Create.
+func (r *Media) SaveVariants(ctx context.Context, mediaId int64, variants []domain.MediaVariant,
+	store func(variant domain.MediaVariant) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, v := range variants {
+		if err := lockHash(ctx, tx, v.Hash); err != nil {
+			return err
+		}
+		if err := store(v); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "INSERT INTO media_variants (media_id, name, mime_type, width, height, hash, size) "+
+			"values ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (media_id, name, mime_type) DO UPDATE "+
+			"SET width=excluded.width, height=excluded.height, hash=excluded.hash, size=excluded.size",
+			mediaId, v.Name, v.MimeType, v.Width, v.Height, v.Hash, v.Size); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE media SET processed_at=$1 WHERE id=$2", time.Now(), mediaId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Media) GetVariant(ctx context.Context, mediaId int64, name, mimeType string) (domain.MediaVariant, error) {
+	var variant domain.MediaVariant
+	err := scanVariant(r.db.QueryRowContext(ctx, "SELECT "+variantColumns+" FROM media_variants "+
+		"WHERE media_id=$1 AND name=$2 AND mime_type=$3", mediaId, name, mimeType), &mediaId, &variant)
+	if err == sql.ErrNoRows {
+		return variant, domain.ErrMediaNotFound
+	}
+
+	return variant, err
+}
+
+// PostIds returns the posts the media is attached to.
+func (r *Media) PostIds(ctx context.Context, mediaId int64) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT post_id FROM post_media WHERE media_id=$1", mediaId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// removeContent calls remove for the hash unless a new upload or variant of the same content has been recorded.
+func (r *Media) removeContent(ctx context.Context, hash string, remove func(hash string) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockHash(ctx, tx, hash); err != nil {
+		return err
+	}
+	var used bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM media WHERE hash=$1) "+
+		"OR EXISTS(SELECT 1 FROM media_variants WHERE hash=$1)", hash).Scan(&used); err != nil {
+		return err
+	}
+	if !used {
+		if err := remove(hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// checkOwners makes sure the post and the media both belong to the user.
+func (r *Media) checkOwners(ctx context.Context, postId, mediaId, userId int64) error {
+	var authorId int64
+	err := r.db.QueryRowContext(ctx, "SELECT author_id FROM posts WHERE id=$1 AND deleted_at IS NULL", postId).Scan(&authorId)
+	if err == sql.ErrNoRows {
+		return domain.ErrPostNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if authorId != userId {
+		return domain.ErrForbidden
+	}
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM media WHERE id=$1 AND owner_id=$2)", mediaId, userId).
+		Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return domain.ErrMediaNotFound
+	}
+
+	return nil
+}
+
+// loadMedia fills the attachments of the posts with a single query.
+func loadMedia(ctx context.Context, db *sql.DB, posts []domain.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.Id)
+	}
+	rows, err := db.QueryContext(ctx, "SELECT pm.post_id, m.id, m.owner_id, m.hash, m.mime_type, m.size, m.createdAt "+
+		"FROM post_media pm JOIN media m ON m.id = pm.media_id WHERE pm.post_id = ANY($1) ORDER BY pm.createdAt, m.id", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	attached := make(map[int64][]domain.Media)
+	mediaIds := make([]int64, 0)
+	for rows.Next() {
+		var postId int64
+		var media domain.Media
+		if err := scanMedia(rows, &media, &postId); err != nil {
+			return err
+		}
+
+		attached[postId] = append(attached[postId], media)
+		mediaIds = append(mediaIds, media.Id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	variants, err := listVariants(ctx, db, mediaIds)
+	if err != nil {
+		return err
+	}
+	for _, uploads := range attached {
+		for i := range uploads {
+			uploads[i].Variants = variants[uploads[i].Id]
+		}
+	}
+
+	for i := range posts {
+		posts[i].Media = attached[posts[i].Id]
+	}
+	return nil
+}
+
+func scanMedia(row scanner, media *domain.Media, prefix ...any) error {
+	dest := append(prefix, &media.Id, &media.OwnerId, &media.Hash, &media.MimeType, &media.Size, &media.CreatedAt)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	media.URL = domain.MediaPath(media.Id)
+
+	return nil
+}
+
+// listVariants returns the variants of the uploads ordered by width, as a srcset lists them.
+// Variants of the types no longer served stay stored until their upload is removed, but are not listed.
+func listVariants(ctx context.Context, db *sql.DB, mediaIds []int64) (map[int64][]domain.MediaVariant, error) {
+	variants := make(map[int64][]domain.MediaVariant)
+	if len(mediaIds) == 0 {
+		return variants, nil
+	}
+
+	types := make([]string, 0, len(domain.MediaVariantTypes))
+	for t := range domain.MediaVariantTypes {
+		types = append(types, t)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT "+variantColumns+" FROM media_variants WHERE media_id = ANY($1) "+
+		"AND mime_type = ANY($2) ORDER BY media_id, width, mime_type", pq.Array(mediaIds), pq.Array(types))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mediaId int64
+		var variant domain.MediaVariant
+		if err := scanVariant(rows, &mediaId, &variant); err != nil {
+			return nil, err
+		}
+
+		variants[mediaId] = append(variants[mediaId], variant)
+	}
+
+	return variants, rows.Err()
+}
+
+func scanVariant(row scanner, mediaId *int64, variant *domain.MediaVariant) error {
+	if err := row.Scan(mediaId, &variant.Name, &variant.MimeType, &variant.Width, &variant.Height, &variant.Hash,
+		&variant.Size); err != nil {
+		return err
+	}
+	variant.URL = domain.MediaVariantPath(*mediaId, variant.Name, variant.MimeType)
+
+	return nil
+}
+
+// lockHash serializes the uploads and the removals of the same content until the transaction ends.
+func lockHash(ctx context.Context, tx *sql.Tx, hash string) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", hash)
+
+	return err
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/lib/pq"
+	"time"
+)
+
+// AuditOutbox keeps the audit events until the relay has delivered them to the audit service.
+type AuditOutbox struct {
+	db *sql.DB
+}
+
+func NewAuditOutbox(db *sql.DB) *AuditOutbox {
+	return &AuditOutbox{db}
+}
+
+// Relay locks up to limit undelivered events, the oldest first, and passes them to deliver.
+// The events deliver returns the ids of are marked as delivered, the rest are passed again by the next relay.
+// The events locked by the relay of another replica are skipped, so every replica may relay at once.
+// The transaction holding the locks stays open while deliver sends the events, so the limit is kept small
+// and deliver must return soon.
+func (r *AuditOutbox) Relay(ctx context.Context, limit int, deliver func(events []domain.AuditEvent) []int64) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, key, entity, action, entity_id, user_id, timestamp FROM audit_outbox "+
+		"WHERE delivered_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED", limit)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var events []domain.AuditEvent
+	for rows.Next() {
+		var event domain.AuditEvent
+		if err := rows.Scan(&event.Id, &event.Key, &event.Entity, &event.Action, &event.EntityId, &event.UserId,
+			&event.Timestamp); err != nil {
+			return 0, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	delivered := deliver(events)
+	if len(delivered) == 0 {
+		return 0, nil
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE audit_outbox SET delivered_at=now() WHERE id = ANY($1)",
+		pq.Array(delivered)); err != nil {
+		return 0, err
+	}
+	return len(delivered), tx.Commit()
+}
+
+// PurgeDelivered removes the events delivered before the given time.
+func (r *AuditOutbox) PurgeDelivered(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM audit_outbox WHERE delivered_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// insertAuditEvent writes the event to the outbox in the transaction of the change it describes.
+func insertAuditEvent(ctx context.Context, tx *sql.Tx, event domain.AuditEvent) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO audit_outbox (entity, action, entity_id, user_id, timestamp) "+
+		"values ($1, $2, $3, $4, $5)", event.Entity, event.Action, event.EntityId, event.UserId, event.Timestamp)
+	return err
+}
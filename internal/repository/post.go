@@ -3,12 +3,18 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// postColumns are selected for every post, scanPost reads them in the same order.
+const postColumns = "id, title, body, body_html, excerpt, word_count, reading_time, slug, author_id, comments_count, reactions, " +
+	"version, createdAt, updatedAt"
+
 type Posts struct {
 	db *sql.DB
 }
@@ -17,33 +23,181 @@ func NewPosts(db *sql.DB) *Posts {
 	return &Posts{db}
 }
 
-func (r *Posts) Create(ctx context.Context, post domain.Post) (domain.Post, error) {
-	err := r.db.QueryRowContext(ctx, "INSERT INTO posts (title, body, author_id) values ($1, $2, $3) returning id",
-		post.Title, post.Body, post.AuthorId).Scan(&post.Id)
-	return post, err
+// Create stores the post under a unique slug, post.Slug is used as its base.
+// The audit event is written along with the post and gets its id.
+func (r *Posts) Create(ctx context.Context, post domain.Post, event domain.AuditEvent) (domain.Post, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return post, err
+	}
+	defer tx.Rollback()
+
+	post.Slug, err = uniqueSlug(ctx, tx, post.Slug, 0)
+	if err != nil {
+		return post, err
+	}
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO posts (title, body, body_html, excerpt, word_count, reading_time, slug, author_id) "+
+		"values ($1, $2, $3, $4, $5, $6, $7, $8) returning id, version, createdAt, updatedAt",
+		post.Title, post.Body, post.BodyHTML, post.Excerpt, post.WordCount, post.ReadingTime, post.Slug, post.AuthorId).
+		Scan(&post.Id, &post.Version, &post.CreatedAt, &post.UpdatedAt)
+	if err != nil {
+		return post, err
+	}
+	event.EntityId = post.Id
+	if err := insertAuditEvent(ctx, tx, event); err != nil {
+		return post, err
+	}
+
+	return post, tx.Commit()
 }
 
 func (r *Posts) GetById(ctx context.Context, id int64) (domain.Post, error) {
 	var post domain.Post
-	err := r.db.QueryRowContext(ctx, "SELECT id, title, body, createdAt, updatedAt FROM posts WHERE id=$1", id).
-		Scan(&post.Id, &post.Title, &post.Body, &post.CreatedAt, &post.UpdatedAt)
+	err := scanPost(r.db.QueryRowContext(ctx, "SELECT "+postColumns+" FROM posts WHERE id=$1 AND deleted_at IS NULL", id), &post)
+	if err == sql.ErrNoRows {
+		return post, domain.ErrPostNotFound
+	}
+	if err != nil {
+		return post, err
+	}
+
+	return post, r.loadOneMedia(ctx, &post)
+}
+
+// GetBySlug finds the post by its current or one of its previous slugs,
+// the returned post always carries the current one.
+func (r *Posts) GetBySlug(ctx context.Context, slug string) (domain.Post, error) {
+	var post domain.Post
+	err := scanPost(r.db.QueryRowContext(ctx, "SELECT "+postColumns+" FROM posts "+
+		"WHERE (slug=$1 OR id=(SELECT post_id FROM post_slugs WHERE slug=$1)) AND deleted_at IS NULL", slug), &post)
 	if err == sql.ErrNoRows {
 		return post, domain.ErrPostNotFound
 	}
+	if err != nil {
+		return post, err
+	}
 
-	return post, err
+	return post, r.loadOneMedia(ctx, &post)
 }
 
 func (r *Posts) List(ctx context.Context) ([]domain.Post, error) {
-	rows, err := r.db.QueryContext(ctx, "SELECT id, title, body, author_id, createdAt, updatedAt FROM posts")
+	rows, err := r.db.QueryContext(ctx, "SELECT "+postColumns+" FROM posts WHERE deleted_at IS NULL ORDER BY createdAt DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]domain.Post, 0)
+	for rows.Next() {
+		var post domain.Post
+		if err := scanPost(rows, &post); err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return posts, loadMedia(ctx, r.db, posts)
+}
+
+// ListRecent returns up to limit newest posts with the names of their authors,
+// posts of every author when authorId is zero.
+func (r *Posts) ListRecent(ctx context.Context, authorId int64, limit int) ([]domain.FeedEntry, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+postColumns+", (SELECT name FROM users WHERE users.id = posts.author_id) "+
+		"FROM posts WHERE deleted_at IS NULL AND ($1 = 0 OR author_id=$1) ORDER BY createdAt DESC, id DESC LIMIT $2",
+		authorId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]domain.FeedEntry, 0)
+	for rows.Next() {
+		var entry domain.FeedEntry
+		if err := scanPost(rows, &entry.Post, &entry.AuthorName); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// LastChange returns when a post of the author, of any author when authorId is zero, was last updated or deleted.
+// Trashed posts count with the time they were deleted, it is zero when there are no posts at all.
+func (r *Posts) LastChange(ctx context.Context, authorId int64) (time.Time, error) {
+	var changed sql.NullTime
+	err := r.db.QueryRowContext(ctx, "SELECT max(greatest(updatedAt, deleted_at)) FROM posts WHERE $1 = 0 OR author_id=$1",
+		authorId).Scan(&changed)
+
+	return changed.Time, err
+}
+
+// SitemapPages splits the published posts, ordered by id, into pages of size posts
+// and returns the latest update time of each page.
+func (r *Posts) SitemapPages(ctx context.Context, size int) ([]time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT max(updatedAt) FROM "+
+		"(SELECT updatedAt, (row_number() OVER (ORDER BY id) - 1) / $1 AS page FROM posts WHERE deleted_at IS NULL) pages "+
+		"GROUP BY page ORDER BY page", size)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := make([]time.Time, 0)
+	for rows.Next() {
+		var lastMod time.Time
+		if err := rows.Scan(&lastMod); err != nil {
+			return nil, err
+		}
+
+		pages = append(pages, lastMod)
+	}
+
+	return pages, rows.Err()
+}
+
+// EachInSitemapPage calls fn for every post of the page, counted from zero, one row at a time.
+// Only Slug and UpdatedAt of the posts are filled.
+func (r *Posts) EachInSitemapPage(ctx context.Context, page, size int, fn func(post domain.Post) error) error {
+	rows, err := r.db.QueryContext(ctx, "SELECT slug, updatedAt FROM posts WHERE deleted_at IS NULL ORDER BY id OFFSET $1 LIMIT $2",
+		page*size, size)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var post domain.Post
+		if err := rows.Scan(&post.Slug, &post.UpdatedAt); err != nil {
+			return err
+		}
+
+		if err := fn(post); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *Posts) ListTrash(ctx context.Context, authorId int64) ([]domain.Post, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+postColumns+", deleted_at FROM posts "+
+		"WHERE author_id=$1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC", authorId)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	posts := make([]domain.Post, 0)
 	for rows.Next() {
 		var post domain.Post
-		if err := rows.Scan(&post.Id, &post.Title, &post.Body, &post.AuthorId, &post.CreatedAt, &post.UpdatedAt); err != nil {
+		if err := scanPost(rows, &post, &post.DeletedAt); err != nil {
 			return nil, err
 		}
 
@@ -53,12 +207,51 @@ func (r *Posts) List(ctx context.Context) ([]domain.Post, error) {
 	return posts, rows.Err()
 }
 
-func (r *Posts) Delete(ctx context.Context, id int64) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM posts WHERE id=$1", id)
-	return err
+// Delete moves the post to the trash, it stays there until Restore or Purge.
+func (r *Posts) Delete(ctx context.Context, id int64, event domain.AuditEvent) error {
+	return r.change(ctx, event, "UPDATE posts SET deleted_at=$1 WHERE id=$2 AND deleted_at IS NULL", time.Now(), id)
+}
+
+// Restore takes the post back from the trash, it counts as an update so the feeds see it come back.
+func (r *Posts) Restore(ctx context.Context, id int64, authorId int64, event domain.AuditEvent) error {
+	return r.change(ctx, event, "UPDATE posts SET deleted_at=NULL, updatedAt=$3 WHERE id=$1 AND author_id=$2 AND deleted_at IS NOT NULL",
+		id, authorId, time.Now())
 }
 
-func (r *Posts) Update(ctx context.Context, id int64, post domain.UpdatePost) (domain.Post, error) {
+// change applies the query to a post and writes the audit event along with it.
+func (r *Posts) change(ctx context.Context, event domain.AuditEvent, query string, args ...any) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(res, domain.ErrPostNotFound); err != nil {
+		return err
+	}
+	if err := insertAuditEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Purge permanently removes the posts that were trashed before the given time.
+func (r *Posts) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM posts WHERE deleted_at IS NOT NULL AND deleted_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Update changes the post and writes the audit event along with it.
+func (r *Posts) Update(ctx context.Context, id int64, post domain.UpdatePost, event domain.AuditEvent) (domain.Post, error) {
 	setValues := make([]string, 0)
 	args := make([]any, 0)
 	argId := 1
@@ -67,18 +260,51 @@ func (r *Posts) Update(ctx context.Context, id int64, post domain.UpdatePost) (d
 	if err != nil {
 		return domain.Post{}, err
 	}
+	if post.Version != 0 && post.Version != newPost.Version {
+		return domain.Post{}, domain.ErrVersionMismatch
+	}
 
-	if post.Title != "" {
+	if post.Title != nil {
 		setValues = append(setValues, fmt.Sprintf("title=$%d", argId))
-		args = append(args, post.Title)
-		newPost.Title = post.Title
+		args = append(args, *post.Title)
+		newPost.Title = *post.Title
 		argId++
 	}
 
-	if post.Body != "" {
+	if post.Body != nil {
 		setValues = append(setValues, fmt.Sprintf("body=$%d", argId))
-		args = append(args, post.Body)
-		newPost.Body = post.Body
+		args = append(args, *post.Body)
+		newPost.Body = *post.Body
+		argId++
+
+		setValues = append(setValues, fmt.Sprintf("body_html=$%d, excerpt=$%d, word_count=$%d, reading_time=$%d",
+			argId, argId+1, argId+2, argId+3))
+		args = append(args, post.Content.BodyHTML, post.Content.Excerpt, post.Content.WordCount, post.Content.ReadingTime)
+		newPost.PostContent = post.Content
+		argId += 4
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Post{}, err
+	}
+	defer tx.Rollback()
+
+	if post.Slug != "" && !hasSlugBase(newPost.Slug, post.Slug) {
+		slug, err := uniqueSlug(ctx, tx, post.Slug, id)
+		if err != nil {
+			return domain.Post{}, err
+		}
+
+		// keep the old slug, so the links to it are redirected to the new one
+		if _, err := tx.ExecContext(ctx, "INSERT INTO post_slugs (slug, post_id) values ($1, $2) ON CONFLICT (slug) DO NOTHING",
+			newPost.Slug, id); err != nil {
+			return domain.Post{}, err
+		}
+
+		setValues = append(setValues, fmt.Sprintf("slug=$%d", argId))
+		args = append(args, slug)
+		newPost.Slug = slug
 		argId++
 	}
 
@@ -86,10 +312,139 @@ func (r *Posts) Update(ctx context.Context, id int64, post domain.UpdatePost) (d
 	args = append(args, time.Now())
 	argId++
 
+	setValues = append(setValues, "version=version+1")
 	setQuery := strings.Join(setValues, ", ")
 
-	query := fmt.Sprintf("%s %s %s%d", "UPDATE posts SET", setQuery, "WHERE id=$", argId)
-	args = append(args, id)
-	_, err = r.db.ExecContext(ctx, query, args...)
-	return newPost, err
+	// the version is checked by the update itself, so a concurrent update in between is not overwritten
+	query := fmt.Sprintf("UPDATE posts SET %s WHERE id=$%d AND deleted_at IS NULL AND ($%d = 0 OR version=$%d) RETURNING version",
+		setQuery, argId, argId+1, argId+1)
+	args = append(args, id, post.Version)
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&newPost.Version)
+	if err == sql.ErrNoRows {
+		// the post was deleted in between, or the version did not match
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM posts WHERE id=$1 AND deleted_at IS NULL)", id).
+			Scan(&exists); err != nil {
+			return domain.Post{}, err
+		}
+		if !exists {
+			return domain.Post{}, domain.ErrPostNotFound
+		}
+		return domain.Post{}, domain.ErrVersionMismatch
+	}
+	if err != nil {
+		return domain.Post{}, err
+	}
+	if err := insertAuditEvent(ctx, tx, event); err != nil {
+		return domain.Post{}, err
+	}
+
+	return newPost, tx.Commit()
+}
+
+func (r *Posts) loadOneMedia(ctx context.Context, post *domain.Post) error {
+	posts := []domain.Post{*post}
+	if err := loadMedia(ctx, r.db, posts); err != nil {
+		return err
+	}
+
+	*post = posts[0]
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPost(row scanner, post *domain.Post, extra ...any) error {
+	dest := []any{&post.Id, &post.Title, &post.Body, &post.BodyHTML, &post.Excerpt, &post.WordCount, &post.ReadingTime,
+		&post.Slug, &post.AuthorId, &post.CommentsCount, (*reactionCounts)(&post.Reactions), &post.Version, &post.CreatedAt,
+		&post.UpdatedAt}
+	return row.Scan(append(dest, extra...)...)
+}
+
+// reactionCounts reads the jsonb counters of the post reactions.
+type reactionCounts map[string]int
+
+func (c *reactionCounts) Scan(src any) error {
+	raw, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("unexpected reactions type %T", src)
+	}
+
+	return json.Unmarshal(raw, c)
+}
+
+func checkAffected(res sql.Result, notFound error) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// uniqueSlug returns base or base with the first free numeric suffix.
+// A previous slug of the same post is taken back from the history.
+// The slugs sharing the base are locked till the end of tx, so the concurrent posts don't pick the same one.
+func uniqueSlug(ctx context.Context, tx *sql.Tx, base string, postId int64) (string, error) {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", "slug:"+slugRoot(base)); err != nil {
+		return "", err
+	}
+
+	for i := 1; ; i++ {
+		candidate := base
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		var owner int64
+		err := tx.QueryRowContext(ctx, "SELECT id FROM posts WHERE slug=$1 UNION ALL SELECT post_id FROM post_slugs WHERE slug=$1 LIMIT 1",
+			candidate).Scan(&owner)
+		if err == sql.ErrNoRows {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if postId != 0 && owner == postId {
+			_, err := tx.ExecContext(ctx, "DELETE FROM post_slugs WHERE slug=$1 AND post_id=$2", candidate, postId)
+			return candidate, err
+		}
+	}
+}
+
+// slugRoot strips all the numeric suffixes from slug, every candidate of uniqueSlug for a base has the same root
+// as the base, "title-2" may be both a candidate of "title" and a base of its own.
+func slugRoot(slug string) string {
+	for {
+		i := strings.LastIndexByte(slug, '-')
+		if i <= 0 {
+			return slug
+		}
+		if _, err := strconv.Atoi(slug[i+1:]); err != nil {
+			return slug
+		}
+		slug = slug[:i]
+	}
+}
+
+// hasSlugBase reports whether slug is base itself or base with a numeric suffix.
+func hasSlugBase(slug, base string) bool {
+	if slug == base {
+		return true
+	}
+
+	suffix := strings.TrimPrefix(slug, base+"-")
+	if suffix == slug || suffix == "" {
+		return false
+	}
+
+	_, err := strconv.Atoi(suffix)
+	return err == nil
 }
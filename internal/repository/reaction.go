@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/lib/pq"
+)
+
+type Reactions struct {
+	db *sql.DB
+}
+
+func NewReactions(db *sql.DB) *Reactions {
+	return &Reactions{db}
+}
+
+// Add leaves the reaction of the user on the post, adding it again changes nothing.
+// It returns the reaction counters of the post.
+func (r *Reactions) Add(ctx context.Context, postId, userId int64, kind string) (map[string]int, error) {
+	return r.change(ctx, postId, "INSERT INTO post_reactions (post_id, user_id, kind) values ($1, $2, $3) ON CONFLICT DO NOTHING",
+		"UPDATE posts SET reactions = jsonb_set(reactions, ARRAY[$2::text], to_jsonb(COALESCE((reactions->>$2)::int, 0) + 1)) "+
+			"WHERE id=$1 RETURNING reactions",
+		userId, kind)
+}
+
+// Remove takes the reaction of the user back, removing a missing one changes nothing.
+// It returns the reaction counters of the post.
+func (r *Reactions) Remove(ctx context.Context, postId, userId int64, kind string) (map[string]int, error) {
+	return r.change(ctx, postId, "DELETE FROM post_reactions WHERE post_id=$1 AND user_id=$2 AND kind=$3",
+		"UPDATE posts SET reactions = jsonb_set(reactions, ARRAY[$2::text], to_jsonb(GREATEST(COALESCE((reactions->>$2)::int, 0) - 1, 0))) "+
+			"WHERE id=$1 RETURNING reactions",
+		userId, kind)
+}
+
+// change applies the reaction query and moves the counter of the post only when a row was affected,
+// so repeated requests keep the counters right.
+func (r *Reactions) change(ctx context.Context, postId int64, query, counterQuery string, userId int64, kind string) (map[string]int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// the lock orders concurrent reactions on the post
+	var counts reactionCounts
+	err = tx.QueryRowContext(ctx, "SELECT reactions FROM posts WHERE id=$1 AND deleted_at IS NULL FOR UPDATE", postId).Scan(&counts)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrPostNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.ExecContext(ctx, query, postId, userId, kind)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected > 0 {
+		if err := tx.QueryRowContext(ctx, counterQuery, postId, kind).Scan(&counts); err != nil {
+			return nil, err
+		}
+	}
+
+	return counts, tx.Commit()
+}
+
+// ListByUser returns the reactions the user left on each of the given posts.
+func (r *Reactions) ListByUser(ctx context.Context, userId int64, postIds []int64) (map[int64][]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT post_id, kind FROM post_reactions WHERE user_id=$1 AND post_id = ANY($2) "+
+		"ORDER BY post_id, kind", userId, pq.Array(postIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := make(map[int64][]string)
+	for rows.Next() {
+		var postId int64
+		var kind string
+		if err := rows.Scan(&postId, &kind); err != nil {
+			return nil, err
+		}
+
+		reactions[postId] = append(reactions[postId], kind)
+	}
+
+	return reactions, rows.Err()
+}
@@ -15,11 +15,24 @@ func NewUsers(db *sql.DB) *Users {
 	return &Users{db}
 }
 
-func (r *Users) Create(ctx context.Context, user domain.User) error {
-	_, err := r.db.ExecContext(ctx, "INSERT INTO users (name, email, password, registered_at) values ($1, $2, $3, $4)",
-		user.Name, user.Email, user.Password, user.RegisteredAt)
+// Create stores the user and writes the audit event along with it, the event gets the id of the user.
+func (r *Users) Create(ctx context.Context, user domain.User, event domain.AuditEvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO users (name, email, password, registered_at) values ($1, $2, $3, $4) returning id",
+		user.Name, user.Email, user.Password, user.RegisteredAt).Scan(&event.UserId)
+	if err != nil {
+		return err
+	}
+	if err := insertAuditEvent(ctx, tx, event); err != nil {
+		return err
+	}
 
-	return err
+	return tx.Commit()
 }
 
 func (r *Users) GetByCredentials(ctx context.Context, email, password string) (domain.User, error) {
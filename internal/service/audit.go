@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	audit "github.com/Arkosh744/grpc-audit-log/pkg/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+// AuditSink delivers the audit events in batches. A failed batch is sent again as a whole,
+// so the events delivered before the failure may arrive twice.
+type AuditSink interface {
+	SendLogRequests(ctx context.Context, items []audit.LogItem) error
+}
+
+// The policies of the full audit queue.
+const (
+	// AuditOverflowDrop drops the new events, the requests never wait for the audit
+	AuditOverflowDrop = "drop"
+	// AuditOverflowBlock makes the requests wait for a place in the queue
+	AuditOverflowBlock = "block"
+)
+
+type AuditDispatcherConfig struct {
+	QueueSize int
+	BatchSize int
+	// FlushInterval is how long a batch waits for more events before it is sent
+	FlushInterval time.Duration
+	// Overflow is AuditOverflowDrop or AuditOverflowBlock
+	Overflow string
+	// MaxRetries is how many times a failed batch is sent again before its events are dropped
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, it doubles with every retry up to RetryMaxBackoff
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+}
+
+// AuditDispatcher sends the audit events in the background, so the requests don't wait for the audit service.
+// The events are queued by SendLogRequest and delivered to the sink in batches by Run.
+type AuditDispatcher struct {
+	// dropped and delivered count the events, they go first to stay aligned for the atomic operations
+	dropped   uint64
+	delivered uint64
+
+	sink AuditSink
+	cfg  AuditDispatcherConfig
+
+	// mu guards closed, the queue is closed with mu held for writing, so no event is sent to the closed queue
+	mu     sync.RWMutex
+	closed bool
+	queue  chan audit.LogItem
+	done   chan struct{}
+}
+
+// AuditStats counts the events of the dispatcher since it started, Queued is the number of the events waiting now.
+type AuditStats struct {
+	Queued    int
+	Dropped   uint64
+	Delivered uint64
+}
+
+func NewAuditDispatcher(sink AuditSink, cfg AuditDispatcherConfig) *AuditDispatcher {
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = 1
+	}
+
+	return &AuditDispatcher{
+		sink:  sink,
+		cfg:   cfg,
+		queue: make(chan audit.LogItem, cfg.QueueSize),
+		done:  make(chan struct{}),
+	}
+}
+
+// SendLogRequest queues the event. When the queue is full the event is dropped with domain.ErrAuditQueueFull,
+// or with the block policy it waits for a place until ctx is done.
+func (d *AuditDispatcher) SendLogRequest(ctx context.Context, req audit.LogItem) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		atomic.AddUint64(&d.dropped, 1)
+		return domain.ErrAuditClosed
+	}
+	select {
+	case d.queue <- req:
+		return nil
+	default:
+	}
+
+	if d.cfg.Overflow != AuditOverflowBlock {
+		atomic.AddUint64(&d.dropped, 1)
+		return domain.ErrAuditQueueFull
+	}
+	select {
+	case d.queue <- req:
+		return nil
+	case <-ctx.Done():
+		atomic.AddUint64(&d.dropped, 1)
+		return ctx.Err()
+	}
+}
+
+// Run delivers the queued events until the dispatcher is closed and the queue is empty.
+// Once ctx is done the retries stop and the events that are left are dropped.
+func (d *AuditDispatcher) Run(ctx context.Context) {
+	defer close(d.done)
+
+	batch := make([]audit.LogItem, 0, d.cfg.BatchSize)
+	for {
+		item, ok := <-d.queue
+		if !ok {
+			return
+		}
+		batch = append(batch[:0], item)
+		ok = d.fill(&batch)
+		d.deliver(ctx, batch)
+		if !ok {
+			return
+		}
+	}
+}
+
+// Close stops taking the events and waits until the queued ones are delivered or ctx is done.
+func (d *AuditDispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *AuditDispatcher) Stats() AuditStats {
+	return AuditStats{
+		Queued:    len(d.queue),
+		Dropped:   atomic.LoadUint64(&d.dropped),
+		Delivered: atomic.LoadUint64(&d.delivered),
+	}
+}
+
+// fill adds the queued events to the batch until it is full or the flush interval is over.
+// It returns false once the queue is closed and empty.
+func (d *AuditDispatcher) fill(batch *[]audit.LogItem) bool {
+	timer := time.NewTimer(d.cfg.FlushInterval)
+	defer timer.Stop()
+
+	for len(*batch) < d.cfg.BatchSize {
+		select {
+		case item, ok := <-d.queue:
+			if !ok {
+				return false
+			}
+			*batch = append(*batch, item)
+		case <-timer.C:
+			return true
+		}
+	}
+	return true
+}
+
+// deliver sends the batch, retrying with exponential backoff, and drops it once the retries are over or ctx is done.
+func (d *AuditDispatcher) deliver(ctx context.Context, batch []audit.LogItem) {
+	backoff := d.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := d.sink.SendLogRequests(ctx, batch)
+		if err == nil {
+			atomic.AddUint64(&d.delivered, uint64(len(batch)))
+			return
+		}
+		if attempt == d.cfg.MaxRetries || ctx.Err() != nil {
+			atomic.AddUint64(&d.dropped, uint64(len(batch)))
+			logrus.WithFields(logrus.Fields{
+				"method": "AuditDispatcher.deliver",
+			}).Errorf("dropped %d audit events: %s", len(batch), err)
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"method": "AuditDispatcher.deliver",
+		}).Warnf("failed to send %d audit events, retrying in %s: %s", len(batch), backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+		}
+		if backoff *= 2; backoff > d.cfg.RetryMaxBackoff {
+			backoff = d.cfg.RetryMaxBackoff
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	audit "github.com/Arkosh744/grpc-audit-log/pkg/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/magiconair/properties/assert"
+)
+
+// fakeSink records the delivered batches, the first failures calls fail and every call waits for release if set.
+type fakeSink struct {
+	mu       sync.Mutex
+	batches  [][]int64
+	calls    int
+	failures int
+	release  chan struct{}
+}
+
+func (s *fakeSink) SendLogRequests(ctx context.Context, items []audit.LogItem) error {
+	if s.release != nil {
+		select {
+		case <-s.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.calls <= s.failures {
+		return errors.New("audit service is unavailable")
+	}
+	ids := make([]int64, len(items))
+	for i, item := range items {
+		ids[i] = item.EntityID
+	}
+	s.batches = append(s.batches, ids)
+	return nil
+}
+
+func testAuditConfig() AuditDispatcherConfig {
+	return AuditDispatcherConfig{
+		QueueSize:       10,
+		BatchSize:       2,
+		FlushInterval:   10 * time.Millisecond,
+		Overflow:        AuditOverflowDrop,
+		MaxRetries:      3,
+		RetryBackoff:    time.Millisecond,
+		RetryMaxBackoff: 4 * time.Millisecond,
+	}
+}
+
+func sendEvents(t *testing.T, d *AuditDispatcher, ids ...int64) {
+	for _, id := range ids {
+		if err := d.SendLogRequest(context.Background(), audit.LogItem{EntityID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAuditDispatcher_Batches(t *testing.T) {
+	sink := &fakeSink{}
+	d := NewAuditDispatcher(sink, testAuditConfig())
+	sendEvents(t, d, 1, 2, 3, 4, 5)
+	go d.Run(context.Background())
+
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, sink.batches, [][]int64{{1, 2}, {3, 4}, {5}})
+	assert.Equal(t, d.Stats(), AuditStats{Delivered: 5})
+}
+
+func TestAuditDispatcher_FlushInterval(t *testing.T) {
+	sink := &fakeSink{}
+	d := NewAuditDispatcher(sink, testAuditConfig())
+	go d.Run(context.Background())
+	defer d.Close(context.Background())
+
+	sendEvents(t, d, 1)
+	deadline := time.Now().Add(time.Second)
+	for d.Stats().Delivered == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("incomplete batch was not sent")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAuditDispatcher_Retries(t *testing.T) {
+	sink := &fakeSink{failures: 2}
+	d := NewAuditDispatcher(sink, testAuditConfig())
+	sendEvents(t, d, 1)
+	go d.Run(context.Background())
+
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, sink.calls, 3)
+	assert.Equal(t, sink.batches, [][]int64{{1}})
+	assert.Equal(t, d.Stats(), AuditStats{Delivered: 1})
+}
+
+func TestAuditDispatcher_DropsAfterRetries(t *testing.T) {
+	sink := &fakeSink{failures: 10}
+	d := NewAuditDispatcher(sink, testAuditConfig())
+	sendEvents(t, d, 1, 2)
+	go d.Run(context.Background())
+
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, sink.calls, 4)
+	assert.Equal(t, d.Stats(), AuditStats{Dropped: 2})
+}
+
+func TestAuditDispatcher_Overflow(t *testing.T) {
+	cfg := testAuditConfig()
+	cfg.QueueSize = 1
+
+	d := NewAuditDispatcher(&fakeSink{}, cfg)
+	sendEvents(t, d, 1)
+	if err := d.SendLogRequest(context.Background(), audit.LogItem{EntityID: 2}); err != domain.ErrAuditQueueFull {
+		t.Fatalf("send to full queue: %v", err)
+	}
+	assert.Equal(t, d.Stats(), AuditStats{Queued: 1, Dropped: 1})
+
+	cfg.Overflow = AuditOverflowBlock
+	sink := &fakeSink{}
+	d = NewAuditDispatcher(sink, cfg)
+	sendEvents(t, d, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.SendLogRequest(ctx, audit.LogItem{EntityID: 2}); err != context.DeadlineExceeded {
+		t.Fatalf("send to full queue: %v", err)
+	}
+
+	// the blocked event goes in once the queue is drained
+	go d.Run(context.Background())
+	sendEvents(t, d, 3)
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, sink.batches, [][]int64{{1, 3}})
+	assert.Equal(t, d.Stats(), AuditStats{Dropped: 1, Delivered: 2})
+}
+
+func TestAuditDispatcher_CloseTimeout(t *testing.T) {
+	sink := &fakeSink{release: make(chan struct{})}
+	d := NewAuditDispatcher(sink, testAuditConfig())
+	sendEvents(t, d, 1, 2, 3)
+	runCtx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(runCtx)
+		close(done)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.Close(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("close with stuck sink: %v", err)
+	}
+	if err := d.SendLogRequest(context.Background(), audit.LogItem{EntityID: 4}); err != domain.ErrAuditClosed {
+		t.Fatalf("send to closed dispatcher: %v", err)
+	}
+
+	// stopping Run drops the events that are left
+	stop()
+	<-done
+	assert.Equal(t, d.Stats(), AuditStats{Dropped: 4})
+}
@@ -0,0 +1,125 @@
+package service
+
+//go:generate mockgen -source=bookmark.go -destination=mocks/bookmark_mock.go -package=mocks
+
+import (
+	"context"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/cursor"
+	"time"
+)
+
+type BookmarksRepository interface {
+	Add(ctx context.Context, userId, postId int64, list string) error
+	Remove(ctx context.Context, userId, postId int64, list string) error
+	List(ctx context.Context, userId int64, list string, before time.Time, beforePostId int64, limit int) ([]domain.Bookmark, error)
+	ListReadingLists(ctx context.Context, userId int64) ([]domain.ReadingList, error)
+	Bookmarked(ctx context.Context, userId int64, postIds []int64) (map[int64]bool, error)
+}
+
+type Bookmarks struct {
+	repo      BookmarksRepository
+	reactions ReactionsRepository
+}
+
+func NewBookmarks(repo BookmarksRepository, reactions ReactionsRepository) *Bookmarks {
+	return &Bookmarks{
+		repo:      repo,
+		reactions: reactions,
+	}
+}
+
+type bookmarksCursor struct {
+	CreatedAt time.Time `json:"createdAt"`
+	PostId    int64     `json:"postId"`
+}
+
+func (s *Bookmarks) Add(ctx context.Context, userId, postId int64, list string) error {
+	list, err := domain.ReadingListName(list)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Add(ctx, userId, postId, list)
+}
+
+func (s *Bookmarks) Remove(ctx context.Context, userId, postId int64, list string) error {
+	list, err := domain.ReadingListName(list)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Remove(ctx, userId, postId, list)
+}
+
+// List returns a page of the reading list of the user, newest bookmarks first.
+func (s *Bookmarks) List(ctx context.Context, userId int64, list string, pageCursor string, limit int) (domain.BookmarkPage, error) {
+	list, err := domain.ReadingListName(list)
+	if err != nil {
+		return domain.BookmarkPage{}, err
+	}
+
+	var position bookmarksCursor
+	if pageCursor != "" {
+		if err := cursor.Decode(pageCursor, &position); err != nil {
+			return domain.BookmarkPage{}, err
+		}
+	}
+
+	// one extra bookmark tells whether there is a next page
+	bookmarks, err := s.repo.List(ctx, userId, list, position.CreatedAt, position.PostId, limit+1)
+	if err != nil {
+		return domain.BookmarkPage{}, err
+	}
+
+	page := domain.BookmarkPage{}
+	if len(bookmarks) > limit {
+		bookmarks = bookmarks[:limit]
+		last := bookmarks[len(bookmarks)-1]
+		page.NextCursor, err = cursor.Encode(bookmarksCursor{CreatedAt: last.CreatedAt, PostId: last.Post.Id})
+		if err != nil {
+			return domain.BookmarkPage{}, err
+		}
+	}
+
+	posts := make([]domain.Post, 0, len(bookmarks))
+	for i := range bookmarks {
+		renderLegacy(&bookmarks[i].Post)
+		bookmarks[i].Post.Bookmarked = true
+		posts = append(posts, bookmarks[i].Post)
+	}
+	if err := markReactions(ctx, s.reactions, userId, posts); err != nil {
+		return domain.BookmarkPage{}, err
+	}
+	for i := range bookmarks {
+		bookmarks[i].Post = posts[i]
+	}
+
+	page.Bookmarks = bookmarks
+	return page, nil
+}
+
+func (s *Bookmarks) ReadingLists(ctx context.Context, userId int64) ([]domain.ReadingList, error) {
+	return s.repo.ListReadingLists(ctx, userId)
+}
+
+// markBookmarked sets Bookmarked of the posts the user saved with a single query.
+func markBookmarked(ctx context.Context, repo BookmarksRepository, userId int64, posts []domain.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.Id)
+	}
+	bookmarked, err := repo.Bookmarked(ctx, userId, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Bookmarked = bookmarked[posts[i].Id]
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/cache"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+// PostCache keeps the encoded posts between the requests, pkg/cache has the in-process and the Redis backends.
+// Get returns cache.ErrMiss for the keys that are not cached.
+type PostCache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+	Stats(ctx context.Context) (cache.Stats, error)
+}
+
+// PostInvalidations tells the other replicas of the app which posts were changed, so they forget them too.
+type PostInvalidations interface {
+	Publish(ctx context.Context, ids ...int64) error
+	// PublishReset tells them to forget every post
+	PublishReset(ctx context.Context) error
+}
+
+// purger is implemented by the caches private to a replica, they miss the changes while the replica isn't listening.
+// The shared caches are kept, the replica that changed a post has already forgotten it there.
+type purger interface {
+	Purge(ctx context.Context) error
+}
+
+const (
+	// cacheStripes is the number of the locks the ids of the cached posts are spread over.
+	cacheStripes = 64
+	// revalidateTimeout bounds the background reads of the stale posts, no request waits for them.
+	revalidateTimeout = 10 * time.Second
+	// loadTimeout bounds the shared reads of the missing posts, they don't end with the request that started them.
+	loadTimeout = 10 * time.Second
+)
+
+// CachedPosts is the typed access to the PostCache shared by the services that change the posts.
+// The posts are cached on read and forgotten after every committed write, the cache only saves the reads,
+// so its failures are logged and treated as misses.
+type CachedPosts struct {
+	// hits and misses count the loads, they go first to stay aligned for the atomic operations
+	hits   uint64
+	misses uint64
+
+	cache PostCache
+	ttl   time.Duration
+	// stale is how long an expired post is still served while it is read again in the background
+	stale time.Duration
+	// notFoundTTL is how long the ids without a post are remembered
+	notFoundTTL time.Duration
+
+	// broadcast is nil when the app runs as a single replica
+	broadcast PostInvalidations
+
+	// loads coalesces the concurrent reads of the same post into one
+	loads   singleflight.Group
+	stripes [cacheStripes]cacheStripe
+	now     func() time.Time
+}
+
+// cacheStripe counts the invalidations of its ids, a post loaded while one of them happened is not cached,
+// so a read racing with a write never puts the old post back.
+type cacheStripe struct {
+	mu            sync.Mutex
+	invalidations uint64
+}
+
+// cachedPost is the cache entry of a post id, NotFound remembers that there is no post with the id.
+type cachedPost struct {
+	Post       domain.Post `json:"post"`
+	NotFound   bool        `json:"notFound,omitempty"`
+	FreshUntil time.Time   `json:"freshUntil"`
+}
+
+func NewCachedPosts(cache PostCache, ttl time.Duration) *CachedPosts {
+	return &CachedPosts{
+		cache: cache,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// WithStaleWhileRevalidate serves the expired posts for stale more, while one of the requests reads them again.
+func (c *CachedPosts) WithStaleWhileRevalidate(stale time.Duration) *CachedPosts {
+	c.stale = stale
+	return c
+}
+
+// WithNotFoundTTL remembers the ids without a post for ttl, so probing them doesn't reach the database.
+func (c *CachedPosts) WithNotFoundTTL(ttl time.Duration) *CachedPosts {
+	c.notFoundTTL = ttl
+	return c
+}
+
+// WithInvalidations publishes the forgotten posts to the other replicas, see RunInvalidations.
+func (c *CachedPosts) WithInvalidations(broadcast PostInvalidations) *CachedPosts {
+	c.broadcast = broadcast
+	return c
+}
+
+// Load returns the cached post or reads it with load and caches it. The concurrent loads of a post share one read,
+// failed reads are never cached except domain.ErrPostNotFound, which is remembered for the not found TTL.
+// The shared read runs on its own context, every caller stops waiting for it once its ctx is done.
+func (c *CachedPosts) Load(ctx context.Context, id int64, load func(ctx context.Context) (domain.Post, error)) (domain.Post, error) {
+	if entry, ok := c.get(ctx, id); ok {
+		now := c.now()
+		switch {
+		case entry.NotFound:
+			if now.Before(entry.FreshUntil) {
+				atomic.AddUint64(&c.hits, 1)
+				return domain.Post{}, domain.ErrPostNotFound
+			}
+		case now.Before(entry.FreshUntil):
+			atomic.AddUint64(&c.hits, 1)
+			return entry.Post, nil
+		case now.Before(entry.FreshUntil.Add(c.stale)):
+			atomic.AddUint64(&c.hits, 1)
+			c.revalidate(id, load)
+			return entry.Post, nil
+		}
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	loaded := c.loads.DoChan(postKey(id), func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+		defer cancel()
+
+		return c.fill(ctx, id, load)
+	})
+	select {
+	case res := <-loaded:
+		return res.Val.(domain.Post), res.Err
+	case <-ctx.Done():
+		return domain.Post{}, ctx.Err()
+	}
+}
+
+// Forget drops the cached posts, so they are read again with their changes, and tells the other replicas to drop them.
+// It is called once the changes are committed, the loads still reading the old posts don't cache them.
+func (c *CachedPosts) Forget(ctx context.Context, ids ...int64) {
+	if len(ids) == 0 {
+		return
+	}
+
+	c.evict(ctx, ids...)
+	if c.broadcast == nil {
+		return
+	}
+	if err := c.broadcast.Publish(ctx, ids...); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method": "CachedPosts.Forget",
+		}).Error("failed to publish forgotten posts:", err)
+	}
+}
+
+// RunInvalidations forgets the posts changed by the other replicas until ctx is done or invalidations is closed.
+// After a reset every post is forgotten, some of the changes may have been missed.
+func (c *CachedPosts) RunInvalidations(ctx context.Context, invalidations <-chan domain.PostInvalidation) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case invalidation, ok := <-invalidations:
+			if !ok {
+				return
+			}
+			if invalidation.Reset {
+				c.reset(ctx)
+				continue
+			}
+			c.evict(ctx, invalidation.Ids...)
+		}
+	}
+}
+
+// evict drops the cached posts of this replica.
+func (c *CachedPosts) evict(ctx context.Context, ids ...int64) {
+	if err := c.evictKeys(ctx, ids...); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method": "CachedPosts.evict",
+		}).Error("failed to forget cached posts:", err)
+	}
+}
+
+func (c *CachedPosts) evictKeys(ctx context.Context, ids ...int64) error {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		stripe := c.stripe(id)
+		stripe.mu.Lock()
+		stripe.invalidations++
+		stripe.mu.Unlock()
+
+		// the reads started from now on don't wait for the one that may have got the old post
+		c.loads.Forget(postKey(id))
+		keys = append(keys, postKey(id))
+	}
+	return c.cache.Delete(ctx, keys...)
+}
+
+// reset forgets every post cached by this replica, the loads running now don't cache theirs.
+func (c *CachedPosts) reset(ctx context.Context) {
+	c.invalidateAll()
+
+	if cache, ok := c.cache.(purger); ok {
+		if err := cache.Purge(ctx); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"method": "CachedPosts.reset",
+			}).Error("failed to purge cached posts:", err)
+		}
+	}
+}
+
+// Stats counts the loads of this replica and describes the content of the cache.
+func (c *CachedPosts) Stats(ctx context.Context) (domain.CacheStats, error) {
+	stats, err := c.cache.Stats(ctx)
+	if err != nil {
+		return domain.CacheStats{}, err
+	}
+
+	return domain.CacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: stats.Evictions,
+		Size:      stats.Size,
+	}, nil
+}
+
+// Lookup returns the raw cache entry of the key, domain.ErrCacheKeyNotFound when there is none.
+func (c *CachedPosts) Lookup(ctx context.Context, key string) (json.RawMessage, error) {
+	value, err := c.cache.Get(ctx, key)
+	if errors.Is(err, cache.ErrMiss) {
+		return nil, domain.ErrCacheKeyNotFound
+	}
+	return value, err
+}
+
+// FlushKey drops the cache entry of the key. The entry of a post is forgotten by the other replicas too.
+func (c *CachedPosts) FlushKey(ctx context.Context, key string) error {
+	if !strings.HasPrefix(key, postKeyPrefix) {
+		return c.cache.Delete(ctx, key)
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(key, postKeyPrefix), 10, 64)
+	if err != nil {
+		return c.cache.Delete(ctx, key)
+	}
+
+	if err := c.evictKeys(ctx, id); err != nil {
+		return err
+	}
+	if c.broadcast != nil {
+		return c.broadcast.Publish(ctx, id)
+	}
+	return nil
+}
+
+// FlushPrefix drops the cache entries with the keys starting with prefix and returns how many there were.
+// The loads running now don't cache their posts, since any of them may be among the dropped ones.
+// The other replicas can't list their keys by prefix, so they are told to reset instead.
+func (c *CachedPosts) FlushPrefix(ctx context.Context, prefix string) (int, error) {
+	c.invalidateAll()
+	flushed, err := c.cache.DeletePrefix(ctx, prefix)
+	if err != nil {
+		return flushed, err
+	}
+	if c.broadcast != nil {
+		return flushed, c.broadcast.PublishReset(ctx)
+	}
+	return flushed, nil
+}
+
+// revalidate reads the stale post again in the background, joining the read of the post already running.
+func (c *CachedPosts) revalidate(id int64, load func(ctx context.Context) (domain.Post, error)) {
+	c.loads.DoChan(postKey(id), func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), revalidateTimeout)
+		defer cancel()
+
+		return c.fill(ctx, id, load)
+	})
+}
+
+// fill reads the post with load and caches it, unless the post was forgotten during the read.
+func (c *CachedPosts) fill(ctx context.Context, id int64, load func(ctx context.Context) (domain.Post, error)) (domain.Post, error) {
+	stripe := c.stripe(id)
+	stripe.mu.Lock()
+	invalidations := stripe.invalidations
+	stripe.mu.Unlock()
+
+	post, err := load(ctx)
+	entry, ttl := cachedPost{Post: post, FreshUntil: c.now().Add(c.ttl)}, c.ttl+c.stale
+	if errors.Is(err, domain.ErrPostNotFound) && c.notFoundTTL > 0 {
+		entry, ttl = cachedPost{NotFound: true, FreshUntil: c.now().Add(c.notFoundTTL)}, c.notFoundTTL
+	} else if err != nil {
+		return post, err
+	}
+
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+	if stripe.invalidations == invalidations {
+		c.set(ctx, id, entry, ttl)
+	}
+	return post, err
+}
+
+func (c *CachedPosts) get(ctx context.Context, id int64) (cachedPost, bool) {
+	value, err := c.cache.Get(ctx, postKey(id))
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			logrus.WithFields(logrus.Fields{
+				"method": "CachedPosts.get",
+			}).Error("failed to get post from cache:", err)
+		}
+		return cachedPost{}, false
+	}
+
+	var entry cachedPost
+	if err := json.Unmarshal(value, &entry); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method": "CachedPosts.get",
+		}).Error("failed to decode cached post:", err)
+		return cachedPost{}, false
+	}
+	return entry, true
+}
+
+func (c *CachedPosts) set(ctx context.Context, id int64, entry cachedPost, ttl time.Duration) {
+	value, err := json.Marshal(entry)
+	if err == nil {
+		err = c.cache.Set(ctx, postKey(id), value, ttl)
+	}
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method": "CachedPosts.set",
+		}).Error("failed to cache post:", err)
+	}
+}
+
+func (c *CachedPosts) invalidateAll() {
+	for i := range c.stripes {
+		c.stripes[i].mu.Lock()
+		c.stripes[i].invalidations++
+		c.stripes[i].mu.Unlock()
+	}
+}
+
+func (c *CachedPosts) stripe(id int64) *cacheStripe {
+	return &c.stripes[uint64(id)%cacheStripes]
+}
+
+const postKeyPrefix = "post:"
+
+func postKey(id int64) string {
+	return postKeyPrefix + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,683 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	audit "github.com/Arkosh744/grpc-audit-log/pkg/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/cache"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/magiconair/properties/assert"
+)
+
+// fakePosts keeps the posts in memory, afterRead runs once the post is read and before it is returned,
+// so the tests can commit a write while a read is in flight.
+type fakePosts struct {
+	PostsRepository
+
+	mu        sync.Mutex
+	posts     map[int64]domain.Post
+	lastId    int64
+	reads     int
+	err       error
+	afterRead func()
+}
+
+func newFakePosts() *fakePosts {
+	return &fakePosts{posts: make(map[int64]domain.Post)}
+}
+
+func (r *fakePosts) Create(ctx context.Context, post domain.Post, event domain.AuditEvent) (domain.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastId++
+	post.Id, post.Version = r.lastId, 1
+	r.posts[post.Id] = post
+	return post, nil
+}
+
+func (r *fakePosts) GetById(ctx context.Context, id int64) (domain.Post, error) {
+	r.mu.Lock()
+	post, ok := r.posts[id]
+	r.reads++
+	err := r.err
+	afterRead := r.afterRead
+	r.afterRead = nil
+	r.mu.Unlock()
+
+	if afterRead != nil {
+		afterRead()
+	}
+	// as the database, the read fails once its context is done
+	if ctx.Err() != nil {
+		return domain.Post{}, ctx.Err()
+	}
+	if err != nil {
+		return domain.Post{}, err
+	}
+	if !ok {
+		return domain.Post{}, domain.ErrPostNotFound
+	}
+	return post, nil
+}
+
+func (r *fakePosts) readCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.reads
+}
+
+func (r *fakePosts) Update(ctx context.Context, id int64, inp domain.UpdatePost, event domain.AuditEvent) (domain.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	post, ok := r.posts[id]
+	if !ok {
+		return domain.Post{}, domain.ErrPostNotFound
+	}
+	if inp.Title != nil {
+		post.Title = *inp.Title
+	}
+	if inp.Body != nil {
+		post.Body = *inp.Body
+		post.PostContent = inp.Content
+	}
+	post.Version++
+	r.posts[id] = post
+	return post, nil
+}
+
+func (r *fakePosts) Delete(ctx context.Context, id int64, event domain.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.posts, id)
+	return nil
+}
+
+// clock is the time of the cache the tests move forward.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock(c *CachedPosts) *clock {
+	clk := &clock{now: time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC)}
+	c.now = clk.Now
+	return clk
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+type fakeReactions struct{ ReactionsRepository }
+
+func (fakeReactions) ListByUser(ctx context.Context, userId int64, postIds []int64) (map[int64][]string, error) {
+	return nil, nil
+}
+
+type fakeBookmarks struct{ BookmarksRepository }
+
+func (fakeBookmarks) Bookmarked(ctx context.Context, userId int64, postIds []int64) (map[int64]bool, error) {
+	return nil, nil
+}
+
+type fakeAudit struct{}
+
+func (fakeAudit) SendLogRequest(ctx context.Context, req audit.LogItem) error {
+	return nil
+}
+
+// recordedAudit keeps the items it was sent.
+type recordedAudit struct {
+	mu    sync.Mutex
+	items []audit.LogItem
+}
+
+func (a *recordedAudit) SendLogRequest(ctx context.Context, req audit.LogItem) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.items = append(a.items, req)
+	return nil
+}
+
+// testCaches runs the test against every cache backend.
+func testCaches(t *testing.T, test func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts)) {
+	backends := map[string]func(t *testing.T) PostCache{
+		"lru": func(t *testing.T) PostCache {
+			return cache.NewLRU(100)
+		},
+		"redis": func(t *testing.T) PostCache {
+			client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+			t.Cleanup(func() { client.Close() })
+			return cache.NewRedis(client)
+		},
+	}
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			repo := newFakePosts()
+			cached := NewCachedPosts(backend(t), time.Minute)
+			test(t, NewPosts(repo, fakeReactions{}, fakeBookmarks{}, cached, fakeAudit{}), cached, repo)
+		})
+	}
+}
+
+func createPost(t *testing.T, posts *Posts) domain.Post {
+	post, err := posts.Create(context.Background(), domain.Post{Title: "Gopher", Body: "Hello", AuthorId: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return post
+}
+
+func TestPosts_GetByIdReadThrough(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		created := createPost(t, posts)
+
+		for i := 0; i < 3; i++ {
+			post, err := posts.GetById(ctx, created.Id, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, post.Title, "Gopher")
+		}
+		assert.Equal(t, repo.reads, 1)
+	})
+}
+
+func TestPosts_FailedReadsAreNotCached(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		created := createPost(t, posts)
+
+		repo.err = errors.New("connection refused")
+		for i := 0; i < 2; i++ {
+			if _, err := posts.GetById(ctx, created.Id, 1); err == nil {
+				t.Fatal("expected error")
+			}
+		}
+		assert.Equal(t, repo.reads, 2)
+
+		repo.err = nil
+		post, err := posts.GetById(ctx, created.Id, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, post.Title, "Gopher")
+	})
+}
+
+func TestPosts_NoStaleReadAfterUpdate(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		created := createPost(t, posts)
+		if _, err := posts.GetById(ctx, created.Id, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		title := "Gopher 2"
+		if _, err := posts.Update(ctx, created.Id, domain.UpdatePost{Id: created.Id, Title: &title}, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		post, err := posts.GetById(ctx, created.Id, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, post.Title, "Gopher 2")
+		assert.Equal(t, post.Version, int64(2))
+	})
+}
+
+func TestPosts_NoStaleReadAfterDelete(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		created := createPost(t, posts)
+		if _, err := posts.GetById(ctx, created.Id, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := posts.Delete(ctx, created.Id, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := posts.GetById(ctx, created.Id, 1); err != domain.ErrPostNotFound {
+			t.Fatalf("get deleted post: %v", err)
+		}
+	})
+}
+
+func TestPosts_NoStaleReadRacingUpdate(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		created := createPost(t, posts)
+
+		// the post is updated after the read got the old one and before the read caches it
+		title := "Gopher 2"
+		repo.afterRead = func() {
+			if _, err := posts.Update(ctx, created.Id, domain.UpdatePost{Id: created.Id, Title: &title}, 1); err != nil {
+				t.Error(err)
+			}
+		}
+		post, err := posts.GetById(ctx, created.Id, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, post.Title, "Gopher")
+
+		post, err = posts.GetById(ctx, created.Id, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, post.Title, "Gopher 2")
+	})
+}
+
+func TestPosts_NoStaleReadRacingDelete(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		created := createPost(t, posts)
+
+		repo.afterRead = func() {
+			if err := posts.Delete(ctx, created.Id, 1); err != nil {
+				t.Error(err)
+			}
+		}
+		if _, err := posts.GetById(ctx, created.Id, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := posts.GetById(ctx, created.Id, 1); err != domain.ErrPostNotFound {
+			t.Fatalf("get deleted post: %v", err)
+		}
+	})
+}
+
+func TestPosts_ConcurrentReadsShareOneLoad(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		created := createPost(t, posts)
+
+		release := make(chan struct{})
+		repo.afterRead = func() { <-release }
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := posts.GetById(ctx, created.Id, 1); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, repo.readCount(), 1)
+	})
+}
+
+func TestPosts_CanceledReadDoesNotFailSharedLoad(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		created := createPost(t, posts)
+
+		reading, release := make(chan struct{}), make(chan struct{})
+		repo.afterRead = func() {
+			close(reading)
+			<-release
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		canceled := make(chan error)
+		go func() {
+			_, err := posts.GetById(ctx, created.Id, 1)
+			canceled <- err
+		}()
+		<-reading
+		waiting := make(chan domain.Post)
+		go func() {
+			post, err := posts.GetById(context.Background(), created.Id, 1)
+			if err != nil {
+				t.Error(err)
+			}
+			waiting <- post
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		// the client that started the read goes away, it stops waiting at once
+		cancel()
+		assert.Equal(t, <-canceled, context.Canceled)
+
+		close(release)
+		assert.Equal(t, (<-waiting).Title, "Gopher")
+		assert.Equal(t, repo.readCount(), 1)
+	})
+}
+
+func TestPosts_ReadAfterWriteDoesNotJoinOldLoad(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		created := createPost(t, posts)
+
+		reading, release := make(chan struct{}), make(chan struct{})
+		repo.afterRead = func() {
+			close(reading)
+			<-release
+		}
+		done := make(chan domain.Post)
+		go func() {
+			post, err := posts.GetById(ctx, created.Id, 1)
+			if err != nil {
+				t.Error(err)
+			}
+			done <- post
+		}()
+		<-reading
+
+		title := "Gopher 2"
+		if _, err := posts.Update(ctx, created.Id, domain.UpdatePost{Id: created.Id, Title: &title}, 1); err != nil {
+			t.Fatal(err)
+		}
+		post, err := posts.GetById(ctx, created.Id, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, post.Title, "Gopher 2")
+
+		// the read started before the update still gets the old post, but doesn't cache it
+		close(release)
+		assert.Equal(t, (<-done).Title, "Gopher")
+		post, err = posts.GetById(ctx, created.Id, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, post.Title, "Gopher 2")
+	})
+}
+
+func TestPosts_StaleWhileRevalidate(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		cached.WithStaleWhileRevalidate(time.Hour)
+		clk := newClock(cached)
+		created := createPost(t, posts)
+		if _, err := posts.GetById(ctx, created.Id, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		// the post is changed behind the cache and the cached one expires
+		repo.mu.Lock()
+		post := repo.posts[created.Id]
+		post.Title = "Gopher 2"
+		repo.posts[created.Id] = post
+		repo.mu.Unlock()
+		clk.Add(2 * time.Minute)
+
+		post, err := posts.GetById(ctx, created.Id, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, post.Title, "Gopher")
+
+		deadline := time.Now().Add(time.Second)
+		for post.Title != "Gopher 2" {
+			if time.Now().After(deadline) {
+				t.Fatal("stale post was not revalidated")
+			}
+			time.Sleep(10 * time.Millisecond)
+			if post, err = posts.GetById(ctx, created.Id, 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// past the stale window the request waits for the post
+		clk.Add(2 * time.Hour)
+		reads := repo.readCount()
+		if _, err := posts.GetById(ctx, created.Id, 1); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, repo.readCount(), reads+1)
+	})
+}
+
+func TestPosts_NotFoundIsRemembered(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		cached.WithNotFoundTTL(5 * time.Second)
+		clk := newClock(cached)
+
+		for i := 0; i < 3; i++ {
+			if _, err := posts.GetById(ctx, 1, 1); err != domain.ErrPostNotFound {
+				t.Fatalf("get missing post: %v", err)
+			}
+		}
+		assert.Equal(t, repo.readCount(), 1)
+
+		clk.Add(10 * time.Second)
+		if _, err := posts.GetById(ctx, 1, 1); err != domain.ErrPostNotFound {
+			t.Fatalf("get missing post: %v", err)
+		}
+		assert.Equal(t, repo.readCount(), 2)
+
+		// the post created with the remembered id is found at once
+		created := createPost(t, posts)
+		assert.Equal(t, created.Id, int64(1))
+		post, err := posts.GetById(ctx, created.Id, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, post.Title, "Gopher")
+	})
+}
+
+// fakeInvalidations records the published ids and resets.
+type fakeInvalidations struct {
+	mu     sync.Mutex
+	ids    []int64
+	resets int
+}
+
+func (f *fakeInvalidations) Publish(ctx context.Context, ids ...int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.ids = append(f.ids, ids...)
+	return nil
+}
+
+func (f *fakeInvalidations) PublishReset(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.resets++
+	return nil
+}
+
+func TestPosts_ChangesArePublished(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		published := &fakeInvalidations{}
+		cached.WithInvalidations(published)
+
+		created := createPost(t, posts)
+		title := "Gopher 2"
+		if _, err := posts.Update(ctx, created.Id, domain.UpdatePost{Id: created.Id, Title: &title}, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := posts.Delete(ctx, created.Id, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, published.ids, []int64{created.Id, created.Id, created.Id})
+	})
+}
+
+func TestCachedPosts_RunInvalidations(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		invalidations := make(chan domain.PostInvalidation)
+		done := make(chan struct{})
+		go func() {
+			cached.RunInvalidations(ctx, invalidations)
+			close(done)
+		}()
+
+		created := createPost(t, posts)
+		if _, err := posts.GetById(ctx, created.Id, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		// another replica changes the post
+		repo.mu.Lock()
+		post := repo.posts[created.Id]
+		post.Title = "Gopher 2"
+		repo.posts[created.Id] = post
+		repo.mu.Unlock()
+		invalidations <- domain.PostInvalidation{Ids: []int64{created.Id}}
+		close(invalidations)
+		<-done
+
+		post, err := posts.GetById(ctx, created.Id, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, post.Title, "Gopher 2")
+		assert.Equal(t, repo.readCount(), 2)
+	})
+}
+
+func TestPosts_GetByIdAuditsEveryRead(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakePosts()
+	audited := &recordedAudit{}
+	posts := NewPosts(repo, fakeReactions{}, fakeBookmarks{}, NewCachedPosts(cache.NewLRU(100), time.Minute), audited)
+	created := createPost(t, posts)
+
+	for _, userId := range []int64{1, 2, 2} {
+		if _, err := posts.GetById(ctx, created.Id, userId); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := posts.GetById(ctx, created.Id+1, 1); err != domain.ErrPostNotFound {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, repo.readCount(), 2)
+	readers := make([]int64, 0, len(audited.items))
+	for _, item := range audited.items {
+		if item.Action == audit.ACTION_GET {
+			assert.Equal(t, item.EntityID, created.Id)
+			readers = append(readers, item.UserID)
+		}
+	}
+	assert.Equal(t, readers, []int64{1, 2, 2})
+}
+
+func TestCachedPosts_ResetPurgesPrivateCache(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakePosts()
+	cached := NewCachedPosts(cache.NewLRU(100), time.Minute)
+	posts := NewPosts(repo, fakeReactions{}, fakeBookmarks{}, cached, fakeAudit{})
+	created := createPost(t, posts)
+	if _, err := posts.GetById(ctx, created.Id, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	invalidations := make(chan domain.PostInvalidation, 1)
+	invalidations <- domain.PostInvalidation{Reset: true}
+	close(invalidations)
+	cached.RunInvalidations(ctx, invalidations)
+
+	if _, err := posts.GetById(ctx, created.Id, 1); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, repo.readCount(), 2)
+}
+
+func TestCachedPosts_Stats(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		created := createPost(t, posts)
+		for i := 0; i < 3; i++ {
+			if _, err := posts.GetById(ctx, created.Id, 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		stats, err := cached.Stats(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, stats, domain.CacheStats{Hits: 2, Misses: 1, Size: 1})
+	})
+}
+
+func TestCachedPosts_LookupAndFlush(t *testing.T) {
+	testCaches(t, func(t *testing.T, posts *Posts, cached *CachedPosts, repo *fakePosts) {
+		ctx := context.Background()
+		published := &fakeInvalidations{}
+		cached.WithInvalidations(published)
+		first, second := createPost(t, posts), createPost(t, posts)
+		for _, id := range []int64{first.Id, second.Id} {
+			if _, err := posts.GetById(ctx, id, 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+		published.ids = nil
+
+		entry, err := cached.Lookup(ctx, "post:1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cachedEntry cachedPost
+		if err := json.Unmarshal(entry, &cachedEntry); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, cachedEntry.Post.Title, "Gopher")
+		if _, err := cached.Lookup(ctx, "post:3"); err != domain.ErrCacheKeyNotFound {
+			t.Fatalf("lookup missing key: %v", err)
+		}
+
+		// the flushed post is forgotten by the other replicas too
+		if err := cached.FlushKey(ctx, "post:1"); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, published.ids, []int64{1})
+		if _, err := cached.Lookup(ctx, "post:1"); err != domain.ErrCacheKeyNotFound {
+			t.Fatalf("lookup flushed key: %v", err)
+		}
+
+		flushed, err := cached.FlushPrefix(ctx, "post:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, flushed, 1)
+		// the other replicas can't flush by prefix, they forget every post
+		assert.Equal(t, published.resets, 1)
+		if _, err := cached.Lookup(ctx, "post:2"); err != domain.ErrCacheKeyNotFound {
+			t.Fatalf("lookup flushed key: %v", err)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package service
+
+//go:generate mockgen -source=comment.go -destination=mocks/comment_mock.go -package=mocks
+
+import (
+	"context"
+	audit "github.com/Arkosh744/grpc-audit-log/pkg/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/cursor"
+	"time"
+)
+
+type CommentsRepository interface {
+	Create(ctx context.Context, comment domain.Comment, event domain.AuditEvent) (domain.Comment, error)
+	GetById(ctx context.Context, id int64) (domain.Comment, error)
+	ListRoots(ctx context.Context, postId int64, afterId int64, limit int) ([]domain.Comment, error)
+	ListReplies(ctx context.Context, rootIds []int64) ([]domain.Comment, error)
+	Update(ctx context.Context, id int64, body string) (domain.Comment, error)
+	Delete(ctx context.Context, id int64, event domain.AuditEvent) error
+}
+
+// PostGetter is the part of the posts repository the comments need for moderation.
+type PostGetter interface {
+	GetById(ctx context.Context, id int64) (domain.Post, error)
+}
+
+type Comments struct {
+	repo  CommentsRepository
+	posts PostGetter
+	cache *CachedPosts
+}
+
+func NewComments(repo CommentsRepository, posts PostGetter, cache *CachedPosts) *Comments {
+	return &Comments{
+		repo:  repo,
+		posts: posts,
+		cache: cache,
+	}
+}
+
+type commentsCursor struct {
+	LastId int64 `json:"id"`
+}
+
+func (s *Comments) Create(ctx context.Context, postId int64, inp domain.CommentInput, userId int64) (domain.Comment, error) {
+	now := time.Now()
+	// the audit service knows only users and posts, so a new comment is logged as an update of its post,
+	// the create and delete actions of the post entity are left to the post itself
+	comment, err := s.repo.Create(ctx, domain.Comment{
+		PostId:    postId,
+		ParentId:  inp.ParentId,
+		AuthorId:  userId,
+		Body:      inp.Body,
+		CreatedAt: now,
+	}, domain.AuditEvent{
+		Action:    audit.ACTION_UPDATE,
+		Entity:    audit.ENTITY_POST,
+		EntityId:  postId,
+		UserId:    userId,
+		Timestamp: now,
+	})
+	if err != nil {
+		return comment, err
+	}
+	comment.UpdatedAt = comment.CreatedAt
+	// the post is read again with the new comments count
+	s.cache.Forget(ctx, postId)
+
+	return comment, nil
+}
+
+// List returns a page of root comments of the post with their whole reply trees.
+func (s *Comments) List(ctx context.Context, postId int64, pageCursor string, limit int) (domain.CommentPage, error) {
+	var position commentsCursor
+	if pageCursor != "" {
+		if err := cursor.Decode(pageCursor, &position); err != nil {
+			return domain.CommentPage{}, err
+		}
+	}
+
+	if _, err := s.posts.GetById(ctx, postId); err != nil {
+		return domain.CommentPage{}, err
+	}
+
+	// one extra root tells whether there is a next page
+	roots, err := s.repo.ListRoots(ctx, postId, position.LastId, limit+1)
+	if err != nil {
+		return domain.CommentPage{}, err
+	}
+
+	page := domain.CommentPage{}
+	if len(roots) > limit {
+		roots = roots[:limit]
+		page.NextCursor, err = cursor.Encode(commentsCursor{LastId: roots[len(roots)-1].Id})
+		if err != nil {
+			return domain.CommentPage{}, err
+		}
+	}
+	if len(roots) == 0 {
+		page.Comments = roots
+		return page, nil
+	}
+
+	rootIds := make([]int64, 0, len(roots))
+	for _, root := range roots {
+		rootIds = append(rootIds, root.Id)
+	}
+	replies, err := s.repo.ListReplies(ctx, rootIds)
+	if err != nil {
+		return domain.CommentPage{}, err
+	}
+
+	page.Comments = buildThreads(roots, replies)
+	return page, nil
+}
+
+// Update edits the comment of the post, a comment of another post is not found.
+func (s *Comments) Update(ctx context.Context, postId int64, id int64, inp domain.UpdateComment, userId int64) (domain.Comment, error) {
+	comment, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return comment, err
+	}
+	if comment.PostId != postId {
+		return domain.Comment{}, domain.ErrCommentNotFound
+	}
+	if comment.AuthorId != userId {
+		return domain.Comment{}, domain.ErrForbidden
+	}
+
+	return s.repo.Update(ctx, id, inp.Body)
+}
+
+// Delete removes the comment of the post on behalf of its author or the author of the post.
+// A comment of another post is not found.
+func (s *Comments) Delete(ctx context.Context, postId int64, id int64, userId int64) error {
+	comment, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if comment.PostId != postId {
+		return domain.ErrCommentNotFound
+	}
+
+	if comment.AuthorId != userId {
+		post, err := s.posts.GetById(ctx, comment.PostId)
+		if err != nil {
+			return err
+		}
+		if post.AuthorId != userId {
+			return domain.ErrForbidden
+		}
+	}
+
+	// logged as an update of the post, as in Create
+	if err := s.repo.Delete(ctx, id, domain.AuditEvent{
+		Action:    audit.ACTION_UPDATE,
+		Entity:    audit.ENTITY_POST,
+		EntityId:  comment.PostId,
+		UserId:    userId,
+		Timestamp: time.Now(),
+	}); err != nil {
+		return err
+	}
+	s.cache.Forget(ctx, comment.PostId)
+
+	return nil
+}
+
+// buildThreads nests the replies, ordered by id, under their parents.
+func buildThreads(roots, replies []domain.Comment) []domain.Comment {
+	children := make(map[int64][]domain.Comment)
+	for _, reply := range replies {
+		children[*reply.ParentId] = append(children[*reply.ParentId], reply)
+	}
+
+	var attach func(comments []domain.Comment) []domain.Comment
+	attach = func(comments []domain.Comment) []domain.Comment {
+		for i := range comments {
+			if replies, ok := children[comments[i].Id]; ok {
+				comments[i].Replies = attach(replies)
+			}
+		}
+		return comments
+	}
+
+	return attach(roots)
+}
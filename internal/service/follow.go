@@ -0,0 +1,91 @@
+package service
+
+//go:generate mockgen -source=follow.go -destination=mocks/follow_mock.go -package=mocks
+
+import (
+	"context"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/cursor"
+	"time"
+)
+
+type FollowsRepository interface {
+	Follow(ctx context.Context, followerId, authorId int64) error
+	Unfollow(ctx context.Context, followerId, authorId int64) error
+	GetProfile(ctx context.Context, id int64, viewerId int64) (domain.Profile, error)
+	Feed(ctx context.Context, userId int64, before time.Time, beforeId int64, limit int) ([]domain.Post, error)
+}
+
+type Follows struct {
+	repo      FollowsRepository
+	reactions ReactionsRepository
+	bookmarks BookmarksRepository
+}
+
+func NewFollows(repo FollowsRepository, reactions ReactionsRepository, bookmarks BookmarksRepository) *Follows {
+	return &Follows{
+		repo:      repo,
+		reactions: reactions,
+		bookmarks: bookmarks,
+	}
+}
+
+type feedCursor struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Id        int64     `json:"id"`
+}
+
+func (s *Follows) Follow(ctx context.Context, userId, authorId int64) error {
+	if userId == authorId {
+		return domain.ErrFollowSelf
+	}
+
+	return s.repo.Follow(ctx, userId, authorId)
+}
+
+func (s *Follows) Unfollow(ctx context.Context, userId, authorId int64) error {
+	return s.repo.Unfollow(ctx, userId, authorId)
+}
+
+func (s *Follows) Profile(ctx context.Context, id int64, userId int64) (domain.Profile, error) {
+	return s.repo.GetProfile(ctx, id, userId)
+}
+
+// Feed returns a page of posts of the authors the user follows, newest first.
+func (s *Follows) Feed(ctx context.Context, userId int64, pageCursor string, limit int) (domain.FeedPage, error) {
+	var position feedCursor
+	if pageCursor != "" {
+		if err := cursor.Decode(pageCursor, &position); err != nil {
+			return domain.FeedPage{}, err
+		}
+	}
+
+	// one extra post tells whether there is a next page
+	posts, err := s.repo.Feed(ctx, userId, position.CreatedAt, position.Id, limit+1)
+	if err != nil {
+		return domain.FeedPage{}, err
+	}
+
+	page := domain.FeedPage{}
+	if len(posts) > limit {
+		posts = posts[:limit]
+		last := posts[len(posts)-1]
+		page.NextCursor, err = cursor.Encode(feedCursor{CreatedAt: last.CreatedAt, Id: last.Id})
+		if err != nil {
+			return domain.FeedPage{}, err
+		}
+	}
+
+	for i := range posts {
+		renderLegacy(&posts[i])
+	}
+	if err := markReactions(ctx, s.reactions, userId, posts); err != nil {
+		return domain.FeedPage{}, err
+	}
+	if err := markBookmarked(ctx, s.bookmarks, userId, posts); err != nil {
+		return domain.FeedPage{}, err
+	}
+
+	page.Posts = posts
+	return page, nil
+}
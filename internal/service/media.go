@@ -0,0 +1,317 @@
+package service
+
+//go:generate mockgen -source=media.go -destination=mocks/media_mock.go -package=mocks
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/imaging"
+	"github.com/Arkosh744/simpleREST_blog/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// sniffLength is the number of leading bytes the content type is detected from.
+	sniffLength = 512
+
+	// variantsQueueSize bounds the uploads waiting for their variants, the ones that do not fit
+	// are picked up by the next sweep of RunVariants.
+	variantsQueueSize = 64
+	// variantsMaxPixels keeps the decoded source of the variants in memory bounds.
+	variantsMaxPixels = 40_000_000
+	variantsQuality   = 82
+)
+
+type MediaRepository interface {
+	Create(ctx context.Context, media domain.Media, store func() error) (domain.Media, error)
+	GetById(ctx context.Context, id int64) (domain.Media, error)
+	Attach(ctx context.Context, postId, mediaId, userId int64) error
+	Detach(ctx context.Context, postId, mediaId, userId int64) error
+	DeleteOrphans(ctx context.Context, before time.Time, remove func(hash string) error) (int64, error)
+	ListUnprocessed(ctx context.Context, afterId int64, limit int) ([]domain.Media, error)
+	SaveVariants(ctx context.Context, mediaId int64, variants []domain.MediaVariant, store func(variant domain.MediaVariant) error) error
+	GetVariant(ctx context.Context, mediaId int64, name, mimeType string) (domain.MediaVariant, error)
+	PostIds(ctx context.Context, mediaId int64) ([]int64, error)
+}
+
+type Media struct {
+	repo    MediaRepository
+	storage storage.Storage
+	cache   *CachedPosts
+	maxSize int64
+	pending chan domain.Media
+}
+
+func NewMedia(repo MediaRepository, storage storage.Storage, cache *CachedPosts, maxSize int64) *Media {
+	return &Media{
+		repo:    repo,
+		storage: storage,
+		cache:   cache,
+		maxSize: maxSize,
+		pending: make(chan domain.Media, variantsQueueSize),
+	}
+}
+
+// Upload stores the content read from r under its sha256 hash and records it as an upload of the user.
+// The content is rejected when it is larger than the size limit or not one of domain.MediaTypes.
+func (s *Media) Upload(ctx context.Context, userId int64, r io.Reader) (domain.Media, error) {
+	// the content is spooled to disk first, its hash has to be known before it is stored
+	tmp, err := os.CreateTemp("", "media-*")
+	if err != nil {
+		return domain.Media{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return domain.Media{}, err
+	}
+	if size > s.maxSize {
+		return domain.Media{}, domain.ErrMediaTooLarge
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return domain.Media{}, err
+	}
+	mimeType := http.DetectContentType(head[:n])
+	if !domain.ValidMediaType(mimeType) {
+		return domain.Media{}, domain.ErrUnsupportedMedia
+	}
+
+	media := domain.Media{
+		OwnerId:   userId,
+		Hash:      hex.EncodeToString(hash.Sum(nil)),
+		MimeType:  mimeType,
+		Size:      size,
+		CreatedAt: time.Now(),
+	}
+	media, err = s.repo.Create(ctx, media, func() error {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		return s.storage.Put(ctx, storage.Key(media.Hash), tmp, size, media.MimeType)
+	})
+	if err != nil {
+		return media, err
+	}
+
+	select {
+	case s.pending <- media:
+	default:
+	}
+	return media, nil
+}
+
+// Open returns the media with its content, the caller closes the content.
+func (s *Media) Open(ctx context.Context, id int64) (domain.Media, io.ReadCloser, error) {
+	media, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return media, nil, err
+	}
+
+	content, err := s.storage.Open(ctx, storage.Key(media.Hash))
+	if err == storage.ErrNotFound {
+		return media, nil, domain.ErrMediaNotFound
+	}
+
+	return media, content, err
+}
+
+// OpenVariant returns the variant of the media with its content, the caller closes the content.
+func (s *Media) OpenVariant(ctx context.Context, id int64, name, mimeType string) (domain.MediaVariant, io.ReadCloser, error) {
+	variant, err := s.repo.GetVariant(ctx, id, name, mimeType)
+	if err != nil {
+		return variant, nil, err
+	}
+
+	content, err := s.storage.Open(ctx, storage.Key(variant.Hash))
+	if err == storage.ErrNotFound {
+		return variant, nil, domain.ErrMediaNotFound
+	}
+
+	return variant, content, err
+}
+
+func (s *Media) Attach(ctx context.Context, postId, mediaId, userId int64) error {
+	if err := s.repo.Attach(ctx, postId, mediaId, userId); err != nil {
+		return err
+	}
+	// the post is read again with its attachments
+	s.cache.Forget(ctx, postId)
+
+	return nil
+}
+
+func (s *Media) Detach(ctx context.Context, postId, mediaId, userId int64) error {
+	if err := s.repo.Detach(ctx, postId, mediaId, userId); err != nil {
+		return err
+	}
+	// the post is read again with its attachments
+	s.cache.Forget(ctx, postId)
+
+	return nil
+}
+
+// RunGC removes the uploads that no post references once they are older than grace,
+// along with the stored content nothing else uses. It runs every interval and blocks until ctx is done.
+func (s *Media) RunGC(ctx context.Context, interval, grace time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteOrphans(ctx, time.Now().Add(-grace), func(hash string) error {
+				return s.storage.Delete(ctx, storage.Key(hash))
+			})
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"method": "Media.RunGC",
+				}).Error("failed to collect orphaned media:", err)
+				continue
+			}
+			if deleted > 0 {
+				logrus.WithFields(logrus.Fields{
+					"method": "Media.RunGC",
+				}).Infof("collected %d orphaned media", deleted)
+			}
+		}
+	}
+}
+
+// RunVariants generates the variants of the new uploads one at a time. Every interval it also sweeps
+// the uploads left unprocessed by a full queue or a restart. It blocks until ctx is done.
+func (s *Media) RunVariants(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.sweepVariants(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case media := <-s.pending:
+			s.generateVariants(ctx, media)
+		case <-ticker.C:
+			s.sweepVariants(ctx)
+		}
+	}
+}
+
+func (s *Media) sweepVariants(ctx context.Context) {
+	var afterId int64
+	for {
+		uploads, err := s.repo.ListUnprocessed(ctx, afterId, variantsQueueSize)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"method": "Media.RunVariants",
+			}).Error("failed to list unprocessed media:", err)
+			return
+		}
+
+		for _, media := range uploads {
+			if ctx.Err() != nil {
+				return
+			}
+			s.generateVariants(ctx, media)
+			afterId = media.Id
+		}
+		if len(uploads) < variantsQueueSize {
+			return
+		}
+	}
+}
+
+// generateVariants stores the resized variants of the image, an image that can not be decoded
+// is marked as processed without variants, so it is not retried forever.
+func (s *Media) generateVariants(ctx context.Context, media domain.Media) {
+	log := logrus.WithFields(logrus.Fields{
+		"method":  "Media.RunVariants",
+		"mediaId": media.Id,
+	})
+
+	var variants []domain.MediaVariant
+	var contents map[string][]byte
+	content, err := s.storage.Open(ctx, storage.Key(media.Hash))
+	switch {
+	case err == storage.ErrNotFound:
+		log.Error("media content is missing")
+	case err != nil:
+		// left unprocessed, the next sweep retries it
+		log.Error("failed to open media:", err)
+		return
+	default:
+		variants, contents, err = resize(content)
+		content.Close()
+		if err != nil {
+			log.Error("failed to generate media variants:", err)
+		}
+	}
+
+	if err := s.repo.SaveVariants(ctx, media.Id, variants, func(variant domain.MediaVariant) error {
+		content := contents[variant.Hash]
+		return s.storage.Put(ctx, storage.Key(variant.Hash), bytes.NewReader(content), int64(len(content)), variant.MimeType)
+	}); err != nil {
+		log.Error("failed to save media variants:", err)
+		return
+	}
+
+	// the posts were cached with the media without its variants
+	postIds, err := s.repo.PostIds(ctx, media.Id)
+	if err != nil {
+		log.Error("failed to list posts of media:", err)
+		return
+	}
+	s.cache.Forget(ctx, postIds...)
+}
+
+// resize encodes the JPEG variants of every size narrower than the image, and the thumbnail in any case.
+// The returned contents are keyed by the hashes of the variants.
+func resize(r io.Reader) ([]domain.MediaVariant, map[string][]byte, error) {
+	img, err := imaging.Decode(r, variantsMaxPixels)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	variants := make([]domain.MediaVariant, 0, len(domain.MediaSizes))
+	contents := make(map[string][]byte)
+	for i, size := range domain.MediaSizes {
+		if i > 0 && size.Width > img.Rect.Dx() {
+			break
+		}
+		resized := imaging.Fit(img, size.Width)
+
+		var buf bytes.Buffer
+		if err := imaging.EncodeJPEG(&buf, resized, variantsQuality); err != nil {
+			return nil, nil, err
+		}
+
+		sum := sha256.Sum256(buf.Bytes())
+		hash := hex.EncodeToString(sum[:])
+		contents[hash] = buf.Bytes()
+		variants = append(variants, domain.MediaVariant{
+			Name:     size.Name,
+			MimeType: "image/jpeg",
+			Width:    resized.Rect.Dx(),
+			Height:   resized.Rect.Dy(),
+			Hash:     hash,
+			Size:     int64(buf.Len()),
+		})
+	}
+
+	return variants, contents, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// statsTimeout bounds reading the stats of the cache for a scrape.
+const statsTimeout = 5 * time.Second
+
+// CacheCollector exports the stats of the post cache as Prometheus metrics.
+type CacheCollector struct {
+	cache     *CachedPosts
+	hits      *prometheus.Desc
+	misses    *prometheus.Desc
+	evictions *prometheus.Desc
+	size      *prometheus.Desc
+}
+
+func NewCacheCollector(cache *CachedPosts) *CacheCollector {
+	return &CacheCollector{
+		cache:     cache,
+		hits:      prometheus.NewDesc("blog_post_cache_hits_total", "Post reads answered by the cache.", nil, nil),
+		misses:    prometheus.NewDesc("blog_post_cache_misses_total", "Post reads that had to load the post.", nil, nil),
+		evictions: prometheus.NewDesc("blog_post_cache_evictions_total", "Posts dropped to make room in the cache.", nil, nil),
+		size:      prometheus.NewDesc("blog_post_cache_size", "Entries in the cache.", nil, nil),
+	}
+}
+
+func (c *CacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.size
+}
+
+func (c *CacheCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+
+	stats, err := c.cache.Stats(ctx)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method": "CacheCollector.Collect",
+		}).Error("failed to get cache stats:", err)
+		ch <- prometheus.NewInvalidMetric(c.size, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(stats.Size))
+}
+
+// AuditCollector exports the counters of the audit dispatcher as Prometheus metrics.
+type AuditCollector struct {
+	dispatcher *AuditDispatcher
+	queued     *prometheus.Desc
+	dropped    *prometheus.Desc
+	delivered  *prometheus.Desc
+}
+
+func NewAuditCollector(dispatcher *AuditDispatcher) *AuditCollector {
+	return &AuditCollector{
+		dispatcher: dispatcher,
+		queued:     prometheus.NewDesc("blog_audit_events_queued", "Audit events waiting to be sent.", nil, nil),
+		dropped:    prometheus.NewDesc("blog_audit_events_dropped_total", "Audit events dropped on a full queue or after the retries.", nil, nil),
+		delivered:  prometheus.NewDesc("blog_audit_events_delivered_total", "Audit events sent to the audit sink.", nil, nil),
+	}
+}
+
+func (c *AuditCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.queued
+	ch <- c.dropped
+	ch <- c.delivered
+}
+
+func (c *AuditCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.dispatcher.Stats()
+	ch <- prometheus.MustNewConstMetric(c.queued, prometheus.GaugeValue, float64(stats.Queued))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(stats.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.delivered, prometheus.CounterValue, float64(stats.Delivered))
+}
@@ -75,17 +75,17 @@ func (m *MockUsersRepository) EXPECT() *MockUsersRepositoryMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockUsersRepository) Create(ctx context.Context, user domain.User) error {
+func (m *MockUsersRepository) Create(ctx context.Context, user domain.User, event domain.AuditEvent) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, user)
+	ret := m.ctrl.Call(m, "Create", ctx, user, event)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockUsersRepositoryMockRecorder) Create(ctx, user interface{}) *gomock.Call {
+func (mr *MockUsersRepositoryMockRecorder) Create(ctx, user, event interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUsersRepository)(nil).Create), ctx, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUsersRepository)(nil).Create), ctx, user, event)
 }
 
 // GetByCredentials mocks base method.
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditOutbox interface {
+	Relay(ctx context.Context, limit int, deliver func(events []domain.AuditEvent) []int64) (int, error)
+	PurgeDelivered(ctx context.Context, before time.Time) (int64, error)
+}
+
+// auditRelayBudget is how long a batch is sent before the rest of it is left to the next relay,
+// the outbox keeps the events of the batch locked while they are sent.
+const auditRelayBudget = 5 * time.Second
+
+// AuditEventSink delivers an event of the outbox, the sink passes its key on for the receiver to spot the redeliveries.
+type AuditEventSink interface {
+	SendAuditEvent(ctx context.Context, event domain.AuditEvent) error
+}
+
+// AuditRelay delivers the audit events written to the outbox with the changes they describe. The events are marked
+// as delivered only after the sink took them, so each of them is delivered at least once.
+type AuditRelay struct {
+	outbox AuditOutbox
+	sink   AuditEventSink
+	batch  int
+	now    func() time.Time
+}
+
+func NewAuditRelay(outbox AuditOutbox, sink AuditEventSink, batch int) *AuditRelay {
+	return &AuditRelay{
+		outbox: outbox,
+		sink:   sink,
+		batch:  batch,
+		now:    time.Now,
+	}
+}
+
+// Run relays the events every interval, and at once again while the batches are full.
+// It blocks until ctx is done.
+func (r *AuditRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				delivered, err := r.Relay(ctx)
+				if err != nil {
+					logrus.WithFields(logrus.Fields{
+						"method": "AuditRelay.Run",
+					}).Error("failed to relay audit events:", err)
+				}
+				if err != nil || delivered < r.batch {
+					break
+				}
+			}
+		}
+	}
+}
+
+// Relay delivers one batch of the events in order, it stops at the first failure to keep the order,
+// and once the relay budget is spent. It returns the number of the delivered events.
+func (r *AuditRelay) Relay(ctx context.Context) (int, error) {
+	var sendErr error
+	delivered, err := r.outbox.Relay(ctx, r.batch, func(events []domain.AuditEvent) []int64 {
+		deadline := r.now().Add(auditRelayBudget)
+		ids := make([]int64, 0, len(events))
+		for _, event := range events {
+			if !r.now().Before(deadline) {
+				break
+			}
+			if sendErr = r.sink.SendAuditEvent(ctx, event); sendErr != nil {
+				break
+			}
+			ids = append(ids, event.Id)
+		}
+		return ids
+	})
+	if err != nil {
+		return delivered, err
+	}
+	return delivered, sendErr
+}
+
+// RunPurge removes the events delivered longer than retention ago, so the outbox doesn't grow forever.
+// It checks the outbox every interval and blocks until ctx is done.
+func (r *AuditRelay) RunPurge(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := r.outbox.PurgeDelivered(ctx, time.Now().Add(-retention))
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"method": "AuditRelay.RunPurge",
+				}).Error("failed to purge audit outbox:", err)
+				continue
+			}
+			if purged > 0 {
+				logrus.WithFields(logrus.Fields{
+					"method": "AuditRelay.RunPurge",
+				}).Infof("purged %d delivered audit events", purged)
+			}
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	audit "github.com/Arkosh744/grpc-audit-log/pkg/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	grpc_client "github.com/Arkosh744/simpleREST_blog/internal/transport/grpc"
+	"github.com/magiconair/properties/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeAuditServer logs every event once by its idempotency key, as an audit service dropping the redeliveries would,
+// the calls listed in fail are refused.
+type fakeAuditServer struct {
+	audit.UnimplementedAuditServiceServer
+
+	mu     sync.Mutex
+	calls  int
+	fail   map[int]bool
+	keys   []string
+	logged map[string]*audit.LogRequest
+}
+
+func (s *fakeAuditServer) Log(ctx context.Context, req *audit.LogRequest) (*audit.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.fail[s.calls] {
+		return nil, status.Error(codes.Unavailable, "audit log is unavailable")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get(grpc_client.IdempotencyKeyHeader)
+	if len(keys) != 1 {
+		return nil, status.Error(codes.InvalidArgument, "idempotency key is required")
+	}
+	if _, ok := s.logged[keys[0]]; !ok {
+		s.keys = append(s.keys, keys[0])
+		s.logged[keys[0]] = req
+	}
+	return &audit.Empty{}, nil
+}
+
+// startAuditServer serves the fake audit service in process and returns the client connected to it.
+func startAuditServer(t *testing.T, server *fakeAuditServer) *grpc_client.Client {
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	audit.RegisterAuditServiceServer(srv, server)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := grpc_client.NewClientFromConn(conn, grpc_client.Config{})
+	t.Cleanup(func() { client.CloseConnection() })
+	return client
+}
+
+// fakeOutbox keeps the events in memory, the first commits listed in failCommits fail after the delivery.
+type fakeOutbox struct {
+	events      []domain.AuditEvent
+	delivered   map[int64]time.Time
+	commits     int
+	failCommits map[int]bool
+}
+
+func newFakeOutbox(n int) *fakeOutbox {
+	outbox := &fakeOutbox{delivered: make(map[int64]time.Time)}
+	for i := 1; i <= n; i++ {
+		outbox.events = append(outbox.events, domain.AuditEvent{
+			Id:        int64(i),
+			Key:       "key-" + strconv.Itoa(i),
+			Entity:    audit.ENTITY_POST,
+			Action:    audit.ACTION_CREATE,
+			EntityId:  int64(i),
+			UserId:    1,
+			Timestamp: time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC),
+		})
+	}
+	return outbox
+}
+
+func (o *fakeOutbox) Relay(ctx context.Context, limit int, deliver func(events []domain.AuditEvent) []int64) (int, error) {
+	var pending []domain.AuditEvent
+	for _, event := range o.events {
+		if _, ok := o.delivered[event.Id]; !ok && len(pending) < limit {
+			pending = append(pending, event)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	ids := deliver(pending)
+	o.commits++
+	if o.failCommits[o.commits] {
+		return 0, errors.New("connection reset")
+	}
+	for _, id := range ids {
+		o.delivered[id] = time.Now()
+	}
+	return len(ids), nil
+}
+
+func (o *fakeOutbox) PurgeDelivered(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	kept := o.events[:0]
+	for _, event := range o.events {
+		if at, ok := o.delivered[event.Id]; ok && at.Before(before) {
+			purged++
+			continue
+		}
+		kept = append(kept, event)
+	}
+	o.events = kept
+	return purged, nil
+}
+
+func TestAuditRelay_StopsAtFailure(t *testing.T) {
+	server := &fakeAuditServer{fail: map[int]bool{2: true}, logged: make(map[string]*audit.LogRequest)}
+	outbox := newFakeOutbox(3)
+	relay := NewAuditRelay(outbox, startAuditServer(t, server), 10)
+
+	delivered, err := relay.Relay(context.Background())
+	if err == nil {
+		t.Fatal("relay to failing server succeeded")
+	}
+	assert.Equal(t, delivered, 1)
+
+	delivered, err = relay.Relay(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, delivered, 2)
+	assert.Equal(t, server.keys, []string{"key-1", "key-2", "key-3"})
+	assert.Equal(t, len(outbox.delivered), 3)
+
+	req := server.logged["key-2"]
+	assert.Equal(t, req.EntityId, int64(2))
+	assert.Equal(t, req.Action, audit.LogRequest_CREATE)
+	assert.Equal(t, req.Timestamp.AsTime(), outbox.events[1].Timestamp)
+}
+
+func TestAuditRelay_RedeliveryIsIdempotent(t *testing.T) {
+	server := &fakeAuditServer{logged: make(map[string]*audit.LogRequest)}
+	// the events are delivered, but marking them fails, so they are delivered again
+	outbox := newFakeOutbox(3)
+	outbox.failCommits = map[int]bool{1: true}
+	relay := NewAuditRelay(outbox, startAuditServer(t, server), 2)
+
+	if _, err := relay.Relay(context.Background()); err == nil {
+		t.Fatal("relay with failing commit succeeded")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := relay.Relay(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.Equal(t, server.calls, 5)
+	assert.Equal(t, server.keys, []string{"key-1", "key-2", "key-3"})
+	assert.Equal(t, len(outbox.delivered), 3)
+}
+
+func TestAuditRelay_Run(t *testing.T) {
+	server := &fakeAuditServer{logged: make(map[string]*audit.LogRequest)}
+	outbox := newFakeOutbox(5)
+	relay := NewAuditRelay(outbox, startAuditServer(t, server), 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for {
+		server.mu.Lock()
+		logged := len(server.keys)
+		server.mu.Unlock()
+		if logged == 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("events were not relayed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// the full batches are relayed one after another within a tick
+	assert.Equal(t, outbox.commits, 3)
+}
+
+// slowSink takes every event after the given time on the clock of the relay.
+type slowSink struct {
+	clock *time.Time
+	took  time.Duration
+	sent  int
+}
+
+func (s *slowSink) SendAuditEvent(ctx context.Context, event domain.AuditEvent) error {
+	*s.clock = s.clock.Add(s.took)
+	s.sent++
+	return nil
+}
+
+func TestAuditRelay_Budget(t *testing.T) {
+	now := time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC)
+	sink := &slowSink{clock: &now, took: 2 * time.Second}
+	outbox := newFakeOutbox(5)
+	relay := NewAuditRelay(outbox, sink, 10)
+	relay.now = func() time.Time { return now }
+
+	// the events stay locked while they are sent, so the rest of the batch waits for the next relay
+	delivered, err := relay.Relay(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, delivered, 3)
+	assert.Equal(t, sink.sent, 3)
+
+	delivered, err = relay.Relay(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, delivered, 2)
+}
+
+func TestAuditRelay_RunPurge(t *testing.T) {
+	outbox := newFakeOutbox(3)
+	relay := NewAuditRelay(outbox, &slowSink{clock: new(time.Time)}, 2)
+	if _, err := relay.Relay(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		relay.RunPurge(ctx, 10*time.Millisecond, 0)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	// only the pending event is left
+	assert.Equal(t, len(outbox.events), 1)
+	assert.Equal(t, outbox.events[0].Key, "key-3")
+}
@@ -4,86 +4,129 @@ package service
 
 import (
 	"context"
-	customCache "github.com/Arkosh744/FirstCache"
 	audit "github.com/Arkosh744/grpc-audit-log/pkg/domain"
 	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/markdown"
+	"github.com/Arkosh744/simpleREST_blog/pkg/slug"
 	"github.com/sirupsen/logrus"
-	"strconv"
 	"time"
 )
 
 type PostsRepository interface {
-	Create(ctx context.Context, post domain.Post) (domain.Post, error)
+	Create(ctx context.Context, post domain.Post, event domain.AuditEvent) (domain.Post, error)
 	GetById(ctx context.Context, id int64) (domain.Post, error)
+	GetBySlug(ctx context.Context, slug string) (domain.Post, error)
 	List(ctx context.Context) ([]domain.Post, error)
-	Delete(ctx context.Context, id int64) error
-	Update(ctx context.Context, id int64, post domain.UpdatePost) (domain.Post, error)
+	Delete(ctx context.Context, id int64, event domain.AuditEvent) error
+	Update(ctx context.Context, id int64, post domain.UpdatePost, event domain.AuditEvent) (domain.Post, error)
+	ListTrash(ctx context.Context, authorId int64) ([]domain.Post, error)
+	Restore(ctx context.Context, id int64, authorId int64, event domain.AuditEvent) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
 }
 
 type Posts struct {
 	repo        PostsRepository
-	cache       *customCache.Cache
+	reactions   ReactionsRepository
+	bookmarks   BookmarksRepository
+	cache       *CachedPosts
 	auditClient AuditClient
 }
 
-func NewPosts(repo PostsRepository, cache *customCache.Cache, auditClient AuditClient) *Posts {
+func NewPosts(repo PostsRepository, reactions ReactionsRepository, bookmarks BookmarksRepository, cache *CachedPosts,
+	auditClient AuditClient) *Posts {
 	return &Posts{
 		repo:        repo,
+		reactions:   reactions,
+		bookmarks:   bookmarks,
 		cache:       cache,
 		auditClient: auditClient,
 	}
 }
 
-func (p *Posts) Create(ctx context.Context, post domain.Post) error {
-	post.CreatedAt = time.Now()
-	post.UpdatedAt = post.CreatedAt
-	p.cache.Set(strconv.FormatInt(post.Id, 10), post, time.Second*360, ctx)
-	newPost, err := p.repo.Create(ctx, post)
+func (p *Posts) Create(ctx context.Context, post domain.Post) (domain.Post, error) {
+	post.Slug = slug.Make(post.Title)
+	content, err := renderBody(post.Body)
 	if err != nil {
-		return err
+		return domain.Post{}, err
+	}
+	post.PostContent = content
+	newPost, err := p.repo.Create(ctx, post, domain.AuditEvent{
+		Action:    audit.ACTION_CREATE,
+		Entity:    audit.ENTITY_POST,
+		UserId:    post.AuthorId,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return domain.Post{}, err
+	}
+	// the id may be remembered as not found
+	p.cache.Forget(ctx, newPost.Id)
+
+	return newPost, nil
+}
+
+// GetById reads the post through the cache, every successful read is audited for the user who asked for it,
+// whether the post was cached or not.
+func (p *Posts) GetById(ctx context.Context, id int64, userId int64) (domain.Post, error) {
+	post, err := p.cache.Load(ctx, id, func(ctx context.Context) (domain.Post, error) {
+		post, err := p.repo.GetById(ctx, id)
+		renderLegacy(&post)
+		return post, err
+	})
+	if err != nil {
+		return post, err
 	}
 
 	if err := p.auditClient.SendLogRequest(ctx, audit.LogItem{
-		Action:    audit.ACTION_CREATE,
+		Action:    audit.ACTION_GET,
 		Entity:    audit.ENTITY_POST,
-		EntityID:  newPost.Id,
-		UserID:    newPost.AuthorId,
-		Timestamp: newPost.CreatedAt,
+		EntityID:  post.Id,
+		UserID:    userId,
+		Timestamp: post.CreatedAt,
 	}); err != nil {
 		logrus.WithFields(logrus.Fields{
-			"method": "Post.Create",
+			"method": "Post.GetById",
 		}).Error("failed to send log request:", err)
 	}
 
-	return nil
+	err = p.personalizeOne(ctx, userId, &post)
+	return post, err
 }
 
-func (p *Posts) GetById(ctx context.Context, id int64, userId int64) (domain.Post, error) {
-	if post, err := p.cache.Get(strconv.FormatInt(id, 10)); err == nil {
-		return post.Value.(domain.Post), err
-	} else {
-		post, err := p.repo.GetById(ctx, id)
-		if err := p.auditClient.SendLogRequest(ctx, audit.LogItem{
-			Action:    audit.ACTION_GET,
-			Entity:    audit.ENTITY_POST,
-			EntityID:  post.Id,
-			UserID:    userId,
-			Timestamp: post.CreatedAt,
-		}); err != nil {
-			logrus.WithFields(logrus.Fields{
-				"method": "Post.GetById",
-			}).Error("failed to send log request:", err)
-		}
+func (p *Posts) GetBySlug(ctx context.Context, slug string, userId int64) (domain.Post, error) {
+	post, err := p.repo.GetBySlug(ctx, slug)
+	if err != nil {
 		return post, err
 	}
+	renderLegacy(&post)
+	if err := p.personalizeOne(ctx, userId, &post); err != nil {
+		return post, err
+	}
+
+	if err := p.auditClient.SendLogRequest(ctx, audit.LogItem{
+		Action:    audit.ACTION_GET,
+		Entity:    audit.ENTITY_POST,
+		EntityID:  post.Id,
+		UserID:    userId,
+		Timestamp: time.Now(),
+	}); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"method": "Post.GetBySlug",
+		}).Error("failed to send log request:", err)
+	}
+	return post, nil
 }
 
 func (p *Posts) List(ctx context.Context, userId int64) ([]domain.Post, error) {
 	posts, err := p.repo.List(ctx)
-	for _, item := range posts {
-		if _, err := p.cache.Get(strconv.FormatInt(item.Id, 10)); err != nil {
-			p.cache.Set(strconv.FormatInt(item.Id, 10), item, time.Second*360, ctx)
-		}
+	if err != nil {
+		return nil, err
+	}
+	for i := range posts {
+		renderLegacy(&posts[i])
+	}
+	if err := p.personalize(ctx, userId, posts); err != nil {
+		return nil, err
 	}
 
 	if err := p.auditClient.SendLogRequest(ctx, audit.LogItem{
@@ -96,42 +139,159 @@ func (p *Posts) List(ctx context.Context, userId int64) ([]domain.Post, error) {
 			"method": "Post.List",
 		}).Error("failed to send log request:", err)
 	}
-	return posts, err
+	return posts, nil
 }
 
 func (p *Posts) Delete(ctx context.Context, id int64, userId int64) error {
-	if _, err := p.cache.Get(strconv.FormatInt(id, 10)); err == nil {
-		_ = p.cache.Delete(strconv.FormatInt(id, 10))
+	if err := p.repo.Delete(ctx, id, domain.AuditEvent{
+		Action:    audit.ACTION_DELETE,
+		Entity:    audit.ENTITY_POST,
+		EntityId:  id,
+		UserId:    userId,
+		Timestamp: time.Now(),
+	}); err != nil {
+		return err
 	}
-	err := p.repo.Delete(ctx, id)
+	p.cache.Forget(ctx, id)
+
+	return nil
+}
+
+func (p *Posts) Update(ctx context.Context, id int64, post domain.UpdatePost, userId int64) (domain.Post, error) {
+	if post.Title != nil {
+		post.Slug = slug.Make(*post.Title)
+	}
+	if post.Body != nil {
+		content, err := renderBody(*post.Body)
+		if err != nil {
+			return domain.Post{}, err
+		}
+		post.Content = content
+	}
+	newPost, err := p.repo.Update(ctx, id, post, domain.AuditEvent{
+		Action:    audit.ACTION_UPDATE,
+		Entity:    audit.ENTITY_POST,
+		EntityId:  id,
+		UserId:    userId,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return domain.Post{}, err
+	}
+	p.cache.Forget(ctx, id)
+
+	return newPost, nil
+}
+
+func (p *Posts) ListTrash(ctx context.Context, userId int64) ([]domain.Post, error) {
+	posts, err := p.repo.ListTrash(ctx, userId)
+
 	if err := p.auditClient.SendLogRequest(ctx, audit.LogItem{
-		Action:    audit.ACTION_DELETE,
+		Action:    audit.ACTION_LIST,
 		Entity:    audit.ENTITY_POST,
-		EntityID:  id,
 		UserID:    userId,
 		Timestamp: time.Now(),
 	}); err != nil {
 		logrus.WithFields(logrus.Fields{
-			"method": "Post.Delete",
+			"method": "Post.ListTrash",
 		}).Error("failed to send log request:", err)
 	}
-	return err
+	return posts, err
 }
 
-func (p *Posts) Update(ctx context.Context, id int64, post domain.UpdatePost, userId int64) error {
-	newPost, err := p.repo.Update(ctx, id, post)
-	p.cache.Set(strconv.FormatInt(post.Id, 10), newPost, time.Second*360, ctx)
-
-	if err := p.auditClient.SendLogRequest(ctx, audit.LogItem{
+func (p *Posts) Restore(ctx context.Context, id int64, userId int64) error {
+	if err := p.repo.Restore(ctx, id, userId, domain.AuditEvent{
 		Action:    audit.ACTION_UPDATE,
 		Entity:    audit.ENTITY_POST,
-		EntityID:  id,
-		UserID:    userId,
+		EntityId:  id,
+		UserId:    userId,
 		Timestamp: time.Now(),
 	}); err != nil {
+		return err
+	}
+	p.cache.Forget(ctx, id)
+
+	return nil
+}
+
+// RunTrashPurge permanently removes posts that stayed in the trash longer than retention.
+// It checks the trash every interval and blocks until ctx is done.
+func (p *Posts) RunTrashPurge(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := p.repo.Purge(ctx, time.Now().Add(-retention))
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"method": "Post.RunTrashPurge",
+				}).Error("failed to purge trash:", err)
+				continue
+			}
+			if purged > 0 {
+				logrus.WithFields(logrus.Fields{
+					"method": "Post.RunTrashPurge",
+				}).Infof("purged %d posts from trash", purged)
+			}
+		}
+	}
+}
+
+// personalize fills the fields of the posts that depend on the user who asked for them,
+// an anonymous reader has the zero id and none of them.
+func (p *Posts) personalize(ctx context.Context, userId int64, posts []domain.Post) error {
+	if userId == 0 {
+		return nil
+	}
+	if err := markReactions(ctx, p.reactions, userId, posts); err != nil {
+		return err
+	}
+
+	return markBookmarked(ctx, p.bookmarks, userId, posts)
+}
+
+func (p *Posts) personalizeOne(ctx context.Context, userId int64, post *domain.Post) error {
+	posts := []domain.Post{*post}
+	if err := p.personalize(ctx, userId, posts); err != nil {
+		return err
+	}
+
+	*post = posts[0]
+	return nil
+}
+
+// renderBody builds the sanitized HTML of the markdown body and the stats shown along with it.
+func renderBody(body string) (domain.PostContent, error) {
+	rendered, err := markdown.Render(body)
+	if err != nil {
+		return domain.PostContent{}, err
+	}
+
+	words := markdown.WordCount(rendered)
+	return domain.PostContent{
+		BodyHTML:    rendered,
+		Excerpt:     markdown.Excerpt(rendered),
+		WordCount:   words,
+		ReadingTime: markdown.ReadingTime(words),
+	}, nil
+}
+
+// renderLegacy fills the HTML of posts created before the bodies were rendered on write.
+func renderLegacy(post *domain.Post) {
+	if post.BodyHTML != "" || post.Body == "" {
+		return
+	}
+
+	content, err := renderBody(post.Body)
+	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"method": "Post.Update",
-		}).Error("failed to send log request:", err)
+			"method": "Post.renderLegacy",
+		}).Error("failed to render post body:", err)
+		return
 	}
-	return err
+	post.PostContent = content
 }
@@ -0,0 +1,79 @@
+package service
+
+//go:generate mockgen -source=reaction.go -destination=mocks/reaction_mock.go -package=mocks
+
+import (
+	"context"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+)
+
+type ReactionsRepository interface {
+	Add(ctx context.Context, postId, userId int64, kind string) (map[string]int, error)
+	Remove(ctx context.Context, postId, userId int64, kind string) (map[string]int, error)
+	ListByUser(ctx context.Context, userId int64, postIds []int64) (map[int64][]string, error)
+}
+
+type Reactions struct {
+	repo  ReactionsRepository
+	cache *CachedPosts
+}
+
+func NewReactions(repo ReactionsRepository, cache *CachedPosts) *Reactions {
+	return &Reactions{
+		repo:  repo,
+		cache: cache,
+	}
+}
+
+// React leaves the reaction of the user on the post and returns the reaction counters of the post.
+func (s *Reactions) React(ctx context.Context, postId int64, kind string, userId int64) (map[string]int, error) {
+	if !domain.ValidReaction(kind) {
+		return nil, domain.ErrInvalidReaction
+	}
+
+	counts, err := s.repo.Add(ctx, postId, userId, kind)
+	if err != nil {
+		return nil, err
+	}
+	// the post is read again with the new counters
+	s.cache.Forget(ctx, postId)
+
+	return counts, nil
+}
+
+// Unreact takes the reaction of the user back and returns the reaction counters of the post.
+func (s *Reactions) Unreact(ctx context.Context, postId int64, kind string, userId int64) (map[string]int, error) {
+	if !domain.ValidReaction(kind) {
+		return nil, domain.ErrInvalidReaction
+	}
+
+	counts, err := s.repo.Remove(ctx, postId, userId, kind)
+	if err != nil {
+		return nil, err
+	}
+	// the post is read again with the new counters
+	s.cache.Forget(ctx, postId)
+
+	return counts, nil
+}
+
+// markReactions fills MyReactions of the posts for the user with a single query.
+func markReactions(ctx context.Context, repo ReactionsRepository, userId int64, posts []domain.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.Id)
+	}
+	mine, err := repo.ListByUser(ctx, userId, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].MyReactions = mine[posts[i].Id]
+	}
+	return nil
+}
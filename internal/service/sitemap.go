@@ -0,0 +1,90 @@
+package service
+
+//go:generate mockgen -source=sitemap.go -destination=mocks/sitemap_mock.go -package=mocks
+
+import (
+	"context"
+	"fmt"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/sitemap"
+	"io"
+	"strings"
+	"time"
+)
+
+type SitemapRepository interface {
+	SitemapPages(ctx context.Context, size int) ([]time.Time, error)
+	EachInSitemapPage(ctx context.Context, page, size int, fn func(post domain.Post) error) error
+}
+
+type Sitemap struct {
+	repo     SitemapRepository
+	siteURL  string
+	pageSize int
+}
+
+func NewSitemap(repo SitemapRepository, siteURL string) *Sitemap {
+	return &Sitemap{
+		repo:     repo,
+		siteURL:  strings.TrimSuffix(siteURL, "/"),
+		pageSize: sitemap.MaxURLs,
+	}
+}
+
+// Write streams the sitemap of the posts to w, or the index of the sitemap pages
+// when the posts do not fit in a single sitemap.
+func (s *Sitemap) Write(ctx context.Context, w io.Writer) error {
+	pages, err := s.repo.SitemapPages(ctx, s.pageSize)
+	if err != nil {
+		return err
+	}
+	if len(pages) <= 1 {
+		return s.writePage(ctx, w, 0)
+	}
+
+	index, err := sitemap.NewIndex(w)
+	if err != nil {
+		return err
+	}
+	for i, lastMod := range pages {
+		if err := index.Add(sitemap.URL{Loc: s.pageURL(i + 1), LastMod: lastMod}); err != nil {
+			return err
+		}
+	}
+
+	return index.Close()
+}
+
+// WritePage streams the page of the sitemap to w, pages are counted from one.
+func (s *Sitemap) WritePage(ctx context.Context, w io.Writer, page int) error {
+	pages, err := s.repo.SitemapPages(ctx, s.pageSize)
+	if err != nil {
+		return err
+	}
+	if page < 1 || page > len(pages) {
+		return domain.ErrSitemapNotFound
+	}
+
+	return s.writePage(ctx, w, page-1)
+}
+
+// pageURL is the address of the sitemap page listed in the index.
+func (s *Sitemap) pageURL(page int) string {
+	return fmt.Sprintf("%s/sitemap/%d.xml", s.siteURL, page)
+}
+
+func (s *Sitemap) writePage(ctx context.Context, w io.Writer, page int) error {
+	urls, err := sitemap.NewURLSet(w)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.EachInSitemapPage(ctx, page, s.pageSize, func(post domain.Post) error {
+		return urls.Add(sitemap.URL{Loc: s.siteURL + post.PublicLink(), LastMod: post.UpdatedAt})
+	})
+	if err != nil {
+		return err
+	}
+
+	return urls.Close()
+}
@@ -0,0 +1,122 @@
+package service
+
+//go:generate mockgen -source=syndication.go -destination=mocks/syndication_mock.go -package=mocks
+
+import (
+	"context"
+	"fmt"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/feed"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FeedSize is the number of newest posts in the syndication feeds.
+const FeedSize = 20
+
+type SyndicationRepository interface {
+	ListRecent(ctx context.Context, authorId int64, limit int) ([]domain.FeedEntry, error)
+	LastChange(ctx context.Context, authorId int64) (time.Time, error)
+}
+
+type ProfilesRepository interface {
+	GetProfile(ctx context.Context, id int64, viewerId int64) (domain.Profile, error)
+}
+
+type Syndication struct {
+	repo     SyndicationRepository
+	profiles ProfilesRepository
+	siteURL  string
+}
+
+func NewSyndication(repo SyndicationRepository, profiles ProfilesRepository, siteURL string) *Syndication {
+	return &Syndication{
+		repo:     repo,
+		profiles: profiles,
+		siteURL:  strings.TrimSuffix(siteURL, "/"),
+	}
+}
+
+// BlogFeed returns the newest posts of the blog, self is the path the feed is served at.
+// The feed is updated when any post was last updated or deleted.
+func (s *Syndication) BlogFeed(ctx context.Context, self string) (feed.Feed, error) {
+	entries, updated, err := s.recent(ctx, 0)
+	if err != nil {
+		return feed.Feed{}, err
+	}
+
+	return s.build("Blog", "Latest posts", self, updated, entries), nil
+}
+
+// AuthorFeed returns the newest posts of the author, self is the path the feed is served at.
+// The feed is updated when a post of the author was last updated or deleted, or when the author registered.
+func (s *Syndication) AuthorFeed(ctx context.Context, authorId int64, self string) (feed.Feed, error) {
+	author, err := s.profiles.GetProfile(ctx, authorId, 0)
+	if err != nil {
+		return feed.Feed{}, err
+	}
+
+	entries, updated, err := s.recent(ctx, authorId)
+	if err != nil {
+		return feed.Feed{}, err
+	}
+	if updated.IsZero() {
+		updated = author.RegisteredAt
+	}
+
+	return s.build(author.Name, "Latest posts of "+author.Name, self, updated, entries), nil
+}
+
+// recent returns the newest posts and when they last changed. The change time is read first, a post changed in
+// between then makes the feed newer than its date and the readers get it again, never the other way round.
+func (s *Syndication) recent(ctx context.Context, authorId int64) ([]domain.FeedEntry, time.Time, error) {
+	updated, err := s.repo.LastChange(ctx, authorId)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	entries, err := s.repo.ListRecent(ctx, authorId, FeedSize)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return entries, updated, nil
+}
+
+func (s *Syndication) build(title, description, self string, updated time.Time, entries []domain.FeedEntry) feed.Feed {
+	f := feed.Feed{
+		Title:       title,
+		Link:        s.siteURL + "/",
+		Self:        s.siteURL + self,
+		Description: description,
+		Updated:     updated,
+	}
+
+	for _, entry := range entries {
+		renderLegacy(&entry.Post)
+
+		f.Items = append(f.Items, feed.Item{
+			Id:        s.guid(entry.Post),
+			Title:     entry.Title,
+			Link:      s.siteURL + entry.PublicLink(),
+			Author:    entry.AuthorName,
+			Summary:   entry.Excerpt,
+			Content:   entry.BodyHTML,
+			Published: entry.CreatedAt,
+			Updated:   entry.UpdatedAt,
+		})
+	}
+
+	return f
+}
+
+// guid is a tag URI of the post, it stays the same when the post or its slug change.
+func (s *Syndication) guid(post domain.Post) string {
+	host := s.siteURL
+	if u, err := url.Parse(s.siteURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+
+	return fmt.Sprintf("tag:%s,%s:post-%d", host, post.CreatedAt.UTC().Format("2006-01-02"), post.Id)
+}
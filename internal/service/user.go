@@ -20,7 +20,7 @@ type PasswordHasher interface {
 }
 
 type UsersRepository interface {
-	Create(ctx context.Context, user domain.User) error
+	Create(ctx context.Context, user domain.User, event domain.AuditEvent) error
 	GetByCredentials(ctx context.Context, email, password string) (domain.User, error)
 }
 
@@ -67,26 +67,11 @@ func (u *Users) SignUp(ctx context.Context, inp domain.SignUpInput) error {
 		RegisteredAt: time.Now(),
 	}
 
-	if err := u.Repo.Create(ctx, user); err != nil {
-		return err
-	}
-
-	user, err = u.Repo.GetByCredentials(ctx, inp.Email, password)
-	if err != nil {
-		return err
-	}
-
-	if err := u.AuditClient.SendLogRequest(ctx, audit.LogItem{
+	return u.Repo.Create(ctx, user, domain.AuditEvent{
 		Action:    audit.ACTION_REGISTER,
 		Entity:    audit.ENTITY_USER,
-		UserID:    user.ID,
 		Timestamp: time.Now(),
-	}); err != nil {
-		logrus.WithFields(logrus.Fields{
-			"method": "Users.SignUp",
-		}).Error("failed to send log request:", err)
-	}
-	return nil
+	})
 }
 
 func (u *Users) SignIn(ctx context.Context, inp domain.SignInInput) (string, string, error) {
@@ -0,0 +1,106 @@
+package auditlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	audit "github.com/Arkosh744/grpc-audit-log/pkg/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+)
+
+// record is a line of the JSON Lines log, Key is set for the events of the outbox only.
+type record struct {
+	Key       string    `json:"key,omitempty"`
+	Entity    string    `json:"entity"`
+	Action    string    `json:"action"`
+	EntityId  int64     `json:"entity_id"`
+	UserId    int64     `json:"user_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// JSONLines writes every event as a JSON object on its own line.
+type JSONLines struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+func NewJSONLines(w io.WriteCloser) *JSONLines {
+	return &JSONLines{w: w}
+}
+
+// NewStdout writes the events to the standard output, closing the sink leaves it open.
+func NewStdout() *JSONLines {
+	return NewJSONLines(nopCloser{Writer: os.Stdout})
+}
+
+// NewFile appends the events to the file at path, rotating it as RotatingFile does.
+func NewFile(path string, maxSize int64, maxBackups int) (*JSONLines, error) {
+	file, err := OpenRotatingFile(path, maxSize, maxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJSONLines(file), nil
+}
+
+func (s *JSONLines) SendLogRequests(ctx context.Context, items []audit.LogItem) error {
+	records := make([]record, 0, len(items))
+	for _, item := range items {
+		records = append(records, record{
+			Entity:    item.Entity,
+			Action:    item.Action,
+			EntityId:  item.EntityID,
+			UserId:    item.UserID,
+			Timestamp: item.Timestamp,
+		})
+	}
+	return s.write(records)
+}
+
+func (s *JSONLines) SendAuditEvent(ctx context.Context, event domain.AuditEvent) error {
+	return s.write([]record{{
+		Key:       event.Key,
+		Entity:    event.Entity,
+		Action:    event.Action,
+		EntityId:  event.EntityId,
+		UserId:    event.UserId,
+		Timestamp: event.Timestamp,
+	}})
+}
+
+func (s *JSONLines) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w.Close()
+}
+
+// write encodes the records first and writes them at once, so the lines of the concurrent writers don't mix.
+func (s *JSONLines) write(records []record) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,46 @@
+package auditlog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	audit "github.com/Arkosh744/grpc-audit-log/pkg/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/magiconair/properties/assert"
+)
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	sink, err := NewFile(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC)
+
+	if err := sink.SendLogRequests(context.Background(), []audit.LogItem{
+		{Entity: audit.ENTITY_POST, Action: audit.ACTION_GET, EntityID: 1, UserID: 2, Timestamp: at},
+		{Entity: audit.ENTITY_POST, Action: audit.ACTION_LIST, UserID: 2, Timestamp: at},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.SendAuditEvent(context.Background(), domain.AuditEvent{
+		Id: 7, Key: "key-7", Entity: audit.ENTITY_USER, Action: audit.ACTION_REGISTER, UserId: 3, Timestamp: at,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(data),
+		`{"entity":"POST","action":"GET","entity_id":1,"user_id":2,"timestamp":"2022-10-12T15:21:56Z"}`+"\n"+
+			`{"entity":"POST","action":"LIST","entity_id":0,"user_id":2,"timestamp":"2022-10-12T15:21:56Z"}`+"\n"+
+			`{"key":"key-7","entity":"USER","action":"REGISTER","entity_id":0,"user_id":3,"timestamp":"2022-10-12T15:21:56Z"}`+"\n")
+}
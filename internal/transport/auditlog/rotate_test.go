@@ -0,0 +1,64 @@
+package auditlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	f, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddddddddddd\n", "eeee\n", "ffff\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(path string) string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	assert.Equal(t, read(path), "eeee\nffff\n")
+	// the write larger than the limit is not split
+	assert.Equal(t, read(path+".1"), "dddddddddddd\n")
+	assert.Equal(t, read(path+".2"), "cccc\n")
+	_, err = os.Stat(path + ".3")
+	assert.Equal(t, os.IsNotExist(err), true)
+}
+
+func TestRotatingFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := os.WriteFile(path, []byte("aaaa\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the size of the existing file counts for the rotation
+	f, err := OpenRotatingFile(path, 8, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("bbbb\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path + ".1")
+	assert.Equal(t, string(data), "aaaa\n")
+	data, _ = os.ReadFile(path)
+	assert.Equal(t, string(data), "bbbb\n")
+}
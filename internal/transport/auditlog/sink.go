@@ -0,0 +1,92 @@
+package auditlog
+
+import (
+	"context"
+
+	audit "github.com/Arkosh744/grpc-audit-log/pkg/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	grpc_client "github.com/Arkosh744/simpleREST_blog/internal/transport/grpc"
+	"github.com/sirupsen/logrus"
+)
+
+// Sink is a backend of the audit log, it takes both the queued events and the events of the outbox.
+type Sink interface {
+	SendLogRequests(ctx context.Context, items []audit.LogItem) error
+	SendAuditEvent(ctx context.Context, event domain.AuditEvent) error
+	Close() error
+}
+
+// Noop drops the events, it is used when the audit log is not needed.
+type Noop struct{}
+
+func (Noop) SendLogRequests(ctx context.Context, items []audit.LogItem) error {
+	return nil
+}
+
+func (Noop) SendAuditEvent(ctx context.Context, event domain.AuditEvent) error {
+	return nil
+}
+
+func (Noop) Close() error {
+	return nil
+}
+
+type grpcSink struct {
+	*grpc_client.Client
+}
+
+// NewGRPC sends the events to the audit service, closing the sink closes the connection of the client.
+func NewGRPC(client *grpc_client.Client) Sink {
+	return grpcSink{Client: client}
+}
+
+func (s grpcSink) Close() error {
+	return s.CloseConnection()
+}
+
+// Fanout sends every event to the primary sink and, once it took the event, copies it to the other sinks.
+// Only the primary sink decides whether the event is delivered and sent again. A copy that fails is logged
+// and not retried, so a retry for the primary sink never writes the same event again to the others.
+type Fanout struct {
+	Primary Sink
+	Copies  []Sink
+}
+
+func (f Fanout) SendLogRequests(ctx context.Context, items []audit.LogItem) error {
+	if err := f.Primary.SendLogRequests(ctx, items); err != nil {
+		return err
+	}
+	for _, sink := range f.Copies {
+		if err := sink.SendLogRequests(ctx, items); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"method": "Fanout.SendLogRequests",
+			}).Errorf("failed to copy %d audit events: %s", len(items), err)
+		}
+	}
+	return nil
+}
+
+func (f Fanout) SendAuditEvent(ctx context.Context, event domain.AuditEvent) error {
+	if err := f.Primary.SendAuditEvent(ctx, event); err != nil {
+		return err
+	}
+	for _, sink := range f.Copies {
+		if err := sink.SendAuditEvent(ctx, event); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"method": "Fanout.SendAuditEvent",
+			}).Errorf("failed to copy audit event %s: %s", event.Key, err)
+		}
+	}
+	return nil
+}
+
+// Close closes all of the sinks and returns the first error.
+func (f Fanout) Close() error {
+	err := f.Primary.Close()
+	for _, sink := range f.Copies {
+		if closeErr := sink.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package auditlog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	audit "github.com/Arkosh744/grpc-audit-log/pkg/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/magiconair/properties/assert"
+)
+
+type fakeSink struct {
+	Noop
+	err    error
+	items  int
+	events int
+	closed bool
+}
+
+func (s *fakeSink) SendLogRequests(ctx context.Context, items []audit.LogItem) error {
+	s.items += len(items)
+	return s.err
+}
+
+func (s *fakeSink) SendAuditEvent(ctx context.Context, event domain.AuditEvent) error {
+	s.events++
+	return s.err
+}
+
+func (s *fakeSink) Close() error {
+	s.closed = true
+	return s.err
+}
+
+func TestFanout(t *testing.T) {
+	down := errors.New("audit service is down")
+	primary, failing, copied := &fakeSink{err: down}, &fakeSink{err: errors.New("disk is full")}, &fakeSink{}
+	sinks := Fanout{Primary: primary, Copies: []Sink{failing, copied}}
+
+	// nothing is copied until the primary sink takes the events
+	err := sinks.SendLogRequests(context.Background(), make([]audit.LogItem, 3))
+	assert.Equal(t, errors.Is(err, down), true)
+	assert.Equal(t, sinks.SendAuditEvent(context.Background(), domain.AuditEvent{Key: "key"}), down)
+	assert.Equal(t, copied.items+copied.events, 0)
+
+	// a failed copy doesn't make the event sent again
+	primary.err = nil
+	if err := sinks.SendLogRequests(context.Background(), make([]audit.LogItem, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sinks.SendAuditEvent(context.Background(), domain.AuditEvent{Key: "key"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []*fakeSink{failing, copied} {
+		assert.Equal(t, s.items, 3)
+		assert.Equal(t, s.events, 1)
+	}
+
+	assert.Equal(t, sinks.Close(), failing.err)
+	for _, s := range []*fakeSink{primary, failing, copied} {
+		assert.Equal(t, s.closed, true)
+	}
+}
@@ -2,38 +2,128 @@ package grpc_client
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/breaker"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"time"
 
 	audit "github.com/Arkosh744/grpc-audit-log/pkg/domain"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// IdempotencyKeyHeader is the metadata of the Log calls with the key of the event. The outbox events are delivered
+// at least once, the audit service logs a redelivered event again unless it drops the keys it has already seen.
+const IdempotencyKeyHeader = "idempotency-key"
+
 type Client struct {
-	conn        *grpc.ClientConn
-	auditClient audit.AuditServiceClient
+	conn         *grpc.ClientConn
+	auditClient  audit.AuditServiceClient
+	healthClient healthpb.HealthClient
+	callTimeout  time.Duration
+	// breaker is nil when the calls are never skipped
+	breaker *breaker.Breaker
 }
 
-func NewClient(port int) (*Client, error) {
-	var conn *grpc.ClientConn
+// Config is where the audit service listens, how the connection to it is secured and kept,
+// and when the calls to it give up.
+type Config struct {
+	Addr string
+	// TLS verifies the server with CAFile, or with the system roots when CAFile is empty
+	TLS    bool
+	CAFile string
+	// ServerName overrides the name the certificate of the server is checked for, the host of Addr by default
+	ServerName string
+
+	// CallTimeout limits every call, zero leaves the deadline of the caller
+	CallTimeout time.Duration
+	// KeepaliveTime is how long the connection stays idle during a call before the server is pinged,
+	// the server drops the clients pinging more often than its enforcement policy allows
+	KeepaliveTime time.Duration
+	// KeepaliveTimeout is how long the ping waits for the answer before the connection is closed
+	KeepaliveTimeout time.Duration
+	// ReconnectMaxBackoff limits the wait between the attempts to connect again, zero keeps the default of gRPC
+	ReconnectMaxBackoff time.Duration
+	// BreakerFailures calls failed in a row make the client skip the calls for BreakerCooldown, zero never skips them
+	BreakerFailures int
+	BreakerCooldown time.Duration
+}
+
+func NewClient(cfg Config) (*Client, error) {
+	creds := insecure.NewCredentials()
+	if cfg.TLS && cfg.CAFile != "" {
+		var err error
+		if creds, err = credentials.NewClientTLSFromFile(cfg.CAFile, cfg.ServerName); err != nil {
+			return nil, err
+		}
+	} else if cfg.TLS {
+		creds = credentials.NewTLS(&tls.Config{ServerName: cfg.ServerName})
+	}
 
-	addr := fmt.Sprintf(":%d", port)
-	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if cfg.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    cfg.KeepaliveTime,
+			Timeout: cfg.KeepaliveTimeout,
+		}))
+	}
+	if cfg.ReconnectMaxBackoff > 0 {
+		reconnect := backoff.DefaultConfig
+		reconnect.MaxDelay = cfg.ReconnectMaxBackoff
+		opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{Backoff: reconnect}))
+	}
+
+	conn, err := grpc.Dial(cfg.Addr, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{
-		conn:        conn,
-		auditClient: audit.NewAuditServiceClient(conn),
-	}, nil
+	return NewClientFromConn(conn, cfg), nil
+}
+
+// NewClientFromConn uses the connection dialed by the caller, only the call settings of cfg apply.
+func NewClientFromConn(conn *grpc.ClientConn, cfg Config) *Client {
+	c := &Client{
+		conn:         conn,
+		auditClient:  audit.NewAuditServiceClient(conn),
+		healthClient: healthpb.NewHealthClient(conn),
+		callTimeout:  cfg.CallTimeout,
+	}
+	if cfg.BreakerFailures > 0 {
+		c.breaker = breaker.New(cfg.BreakerFailures, cfg.BreakerCooldown)
+	}
+	return c
 }
 
 func (c *Client) CloseConnection() error {
 	return c.conn.Close()
 }
 
+// CheckHealth asks the health service of the server whether it is serving.
+// A server without the health service is taken as serving, it answered after all.
+func (c *Client) CheckHealth(ctx context.Context) error {
+	resp, err := c.healthClient.Check(ctx, &healthpb.HealthCheckRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("audit service is %s", resp.Status)
+	}
+	return nil
+}
+
 func (c *Client) SendLogRequest(ctx context.Context, req audit.LogItem) error {
 	action, err := audit.ToPbAction(req.Action)
 	if err != nil {
@@ -45,13 +135,67 @@ func (c *Client) SendLogRequest(ctx context.Context, req audit.LogItem) error {
 		return err
 	}
 
-	_, err = c.auditClient.Log(ctx, &audit.LogRequest{
+	logReq := &audit.LogRequest{
 		Action:    action,
 		Entity:    entity,
 		EntityId:  req.EntityID,
 		UserId:    req.UserID,
 		Timestamp: timestamppb.New(req.Timestamp),
+	}
+	return c.call(ctx, func(ctx context.Context) error {
+		_, err := c.auditClient.Log(ctx, logReq)
+		return err
 	})
+}
 
+// call runs fn with the call timeout, unless the breaker is open.
+func (c *Client) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	if c.breaker != nil {
+		if err := c.breaker.Allow(); err != nil {
+			return err
+		}
+	}
+	if c.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.callTimeout)
+		defer cancel()
+	}
+
+	err := fn(ctx)
+	if c.breaker != nil {
+		c.breaker.Done(unavailable(err))
+	}
 	return err
 }
+
+// unavailable reports whether the call failed because of the server or the connection, not the request.
+func unavailable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+// SendLogRequests sends the events one by one and stops at the first failure.
+func (c *Client) SendLogRequests(ctx context.Context, items []audit.LogItem) error {
+	for _, item := range items {
+		if err := c.SendLogRequest(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendAuditEvent sends the event of the outbox with its key.
+func (c *Client) SendAuditEvent(ctx context.Context, event domain.AuditEvent) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, IdempotencyKeyHeader, event.Key)
+	return c.SendLogRequest(ctx, audit.LogItem{
+		Action:    event.Action,
+		Entity:    event.Entity,
+		EntityID:  event.EntityId,
+		UserID:    event.UserId,
+		Timestamp: event.Timestamp,
+	})
+}
@@ -0,0 +1,175 @@
+package grpc_client
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	audit "github.com/Arkosh744/grpc-audit-log/pkg/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/breaker"
+	"github.com/magiconair/properties/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeAuditServer answers the Log calls with err after waiting for delay.
+type fakeAuditServer struct {
+	audit.UnimplementedAuditServiceServer
+
+	mu    sync.Mutex
+	calls int
+	err   error
+	delay time.Duration
+}
+
+func (s *fakeAuditServer) Log(ctx context.Context, req *audit.LogRequest) (*audit.Empty, error) {
+	s.mu.Lock()
+	s.calls++
+	err, delay := s.err, s.delay
+	s.mu.Unlock()
+
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &audit.Empty{}, nil
+}
+
+func (s *fakeAuditServer) set(err error, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err, s.delay = err, delay
+}
+
+func (s *fakeAuditServer) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+// startServer serves the fake audit service in process, with the health service when healthServer is not nil.
+func startServer(t *testing.T, server *fakeAuditServer, healthServer *health.Server, cfg Config) *Client {
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	audit.RegisterAuditServiceServer(srv, server)
+	if healthServer != nil {
+		healthpb.RegisterHealthServer(srv, healthServer)
+	}
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClientFromConn(conn, cfg)
+	t.Cleanup(func() { client.CloseConnection() })
+	return client
+}
+
+var testItem = audit.LogItem{
+	Entity:    audit.ENTITY_POST,
+	Action:    audit.ACTION_GET,
+	EntityID:  1,
+	UserID:    1,
+	Timestamp: time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC),
+}
+
+func TestClient_CallTimeout(t *testing.T) {
+	server := &fakeAuditServer{delay: time.Second}
+	client := startServer(t, server, nil, Config{CallTimeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	err := client.SendLogRequest(context.Background(), testItem)
+	assert.Equal(t, status.Code(err), codes.DeadlineExceeded)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("call took %s", elapsed)
+	}
+}
+
+func TestClient_Breaker(t *testing.T) {
+	server := &fakeAuditServer{err: status.Error(codes.InvalidArgument, "bad request")}
+	client := startServer(t, server, nil, Config{BreakerFailures: 2, BreakerCooldown: 100 * time.Millisecond})
+	ctx := context.Background()
+
+	// the rejected requests don't say the server is down
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, status.Code(client.SendLogRequest(ctx, testItem)), codes.InvalidArgument)
+	}
+
+	server.set(status.Error(codes.Unavailable, "audit log is unavailable"), 0)
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, status.Code(client.SendLogRequest(ctx, testItem)), codes.Unavailable)
+	}
+	// the open breaker skips the calls
+	assert.Equal(t, client.SendLogRequests(ctx, []audit.LogItem{testItem, testItem}), breaker.ErrOpen)
+	assert.Equal(t, server.callCount(), 5)
+
+	server.set(nil, 0)
+	time.Sleep(100 * time.Millisecond)
+	if err := client.SendLogRequest(ctx, testItem); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SendLogRequest(ctx, testItem); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, server.callCount(), 7)
+}
+
+func TestClient_CheckHealth(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  healthpb.HealthCheckResponse_ServingStatus
+		health  bool
+		healthy bool
+	}{
+		{
+			name:    "serving",
+			status:  healthpb.HealthCheckResponse_SERVING,
+			health:  true,
+			healthy: true,
+		},
+		{
+			name:    "not serving",
+			status:  healthpb.HealthCheckResponse_NOT_SERVING,
+			health:  true,
+			healthy: false,
+		},
+		{
+			name:    "no health service",
+			healthy: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var healthServer *health.Server
+			if test.health {
+				healthServer = health.NewServer()
+				healthServer.SetServingStatus("", test.status)
+			}
+			client := startServer(t, &fakeAuditServer{}, healthServer, Config{})
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			err := client.CheckHealth(ctx)
+			assert.Equal(t, err == nil, test.healthy)
+		})
+	}
+}
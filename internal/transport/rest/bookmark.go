@@ -0,0 +1,219 @@
+package rest
+
+import (
+	"errors"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/cursor"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBookmarksLimit = 20
+	maxBookmarksLimit     = 100
+)
+
+// ListBookmarks godoc
+// @Summary Get bookmarks
+// @Description Get a page of the bookmarks in a reading list of the current user, newest first
+// @Tags bookmarks
+// @Accept  json
+// @Produce  json
+// @Param list query string false "Reading list name, the default list when empty"
+// @Param cursor query string false "Cursor of the next page"
+// @Param limit query int false "Bookmarks per page, 20 by default and 100 at most"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} domain.BookmarkPage
+// @Router /users/me/bookmarks [get]
+func (h *Handler) ListBookmarks(c *gin.Context) {
+	limit := defaultBookmarksLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxBookmarksLimit {
+			log.WithFields(log.Fields{"handler": "ListBookmarks"}).Error("invalid limit ", value)
+			c.JSON(http.StatusBadRequest, map[string]string{
+				"message": "invalid limit",
+			})
+			return
+		}
+	}
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "ListBookmarks"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "ListBookmarks"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	page, err := h.bookmarksService.List(c, userId, c.Query("list"), c.Query("cursor"), limit)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "ListBookmarks"}).Error(err)
+		bookmarkError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// AddBookmark godoc
+// @Summary Bookmark a post
+// @Description Save a post to a reading list of the current user, saving it twice changes nothing
+// @Tags bookmarks
+// @Accept  json
+// @Produce  json
+// @Param postId path int true "Post ID"
+// @Param list query string false "Reading list name, the default list when empty"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {string} string {"message": "bookmarked"}
+// @Router /users/me/bookmarks/{postId} [put]
+func (h *Handler) AddBookmark(c *gin.Context) {
+	postId, err := strconv.ParseInt(c.Param("postId"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "AddBookmark"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post id",
+		})
+		return
+	}
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "AddBookmark"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "AddBookmark"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.bookmarksService.Add(c, userId, postId, c.Query("list")); err != nil {
+		log.WithFields(log.Fields{"handler": "AddBookmark"}).Error(err)
+		bookmarkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "bookmarked",
+	})
+}
+
+// RemoveBookmark godoc
+// @Summary Remove a bookmark
+// @Description Take a post out of a reading list of the current user, removing a missing one changes nothing
+// @Tags bookmarks
+// @Accept  json
+// @Produce  json
+// @Param postId path int true "Post ID"
+// @Param list query string false "Reading list name, the default list when empty"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {string} string {"message": "removed"}
+// @Router /users/me/bookmarks/{postId} [delete]
+func (h *Handler) RemoveBookmark(c *gin.Context) {
+	postId, err := strconv.ParseInt(c.Param("postId"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "RemoveBookmark"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post id",
+		})
+		return
+	}
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "RemoveBookmark"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "RemoveBookmark"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.bookmarksService.Remove(c, userId, postId, c.Query("list")); err != nil {
+		log.WithFields(log.Fields{"handler": "RemoveBookmark"}).Error(err)
+		bookmarkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "removed",
+	})
+}
+
+// ListReadingLists godoc
+// @Summary Get reading lists
+// @Description Get the reading lists of the current user with the number of bookmarks in each
+// @Tags bookmarks
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {array} []domain.ReadingList
+// @Router /users/me/reading-lists [get]
+func (h *Handler) ListReadingLists(c *gin.Context) {
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "ListReadingLists"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "ListReadingLists"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	lists, err := h.bookmarksService.ReadingLists(c, userId)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "ListReadingLists"}).Error(err)
+		bookmarkError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+// bookmarkError maps the errors of the bookmarks service to the response status.
+func bookmarkError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, cursor.ErrInvalidCursor), errors.Is(err, domain.ErrInvalidReadingList):
+		status = http.StatusBadRequest
+	case errors.Is(err, domain.ErrPostNotFound):
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, map[string]string{
+		"message": err.Error(),
+	})
+}
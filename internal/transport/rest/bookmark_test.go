@@ -0,0 +1,224 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/transport/rest/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_ListBookmarks(t *testing.T) {
+	type mockBehavior func(mockBookmark *mocks.MockBookmarks, mockUser *mocks.MockUsers)
+	var AuthorId int64 = 1
+	createdAt := time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		query                string
+		mockCookie           *http.Cookie
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "Ok",
+			query: "?list=golang&limit=1",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockBookmark *mocks.MockBookmarks, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockBookmark.EXPECT().List(gomock.Any(), AuthorId, "golang", "", 1).Return(domain.BookmarkPage{
+					Bookmarks: []domain.Bookmark{{
+						List:      "golang",
+						CreatedAt: createdAt,
+						Post: domain.Post{
+							Id: 1, Title: "TestTitle", Body: "TestBody", AuthorId: 2, Bookmarked: true,
+							CreatedAt: createdAt, UpdatedAt: createdAt,
+						},
+					}},
+					NextCursor: "next",
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"bookmarks":[{"list":"golang","createdAt":"2022-10-12T15:21:56Z",` +
+				`"post":{"id":1,"title":"TestTitle","body":"TestBody","AuthorId":2,"bookmarked":true,` +
+				`"createdAt":"2022-10-12T15:21:56Z","updatedAt":"2022-10-12T15:21:56Z"}}],"nextCursor":"next"}`,
+		},
+		{
+			name:       "W/o Cookie",
+			query:      "",
+			mockCookie: &http.Cookie{},
+			mockBehavior: func(mockBookmark *mocks.MockBookmarks, mockUser *mocks.MockUsers) {
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"http: named cookie not present"}`,
+		},
+		{
+			name:  "Wrong limit",
+			query: "?limit=0",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockBookmark *mocks.MockBookmarks, mockUser *mocks.MockUsers) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid limit"}`,
+		},
+		{
+			name:  "Wrong list",
+			query: "",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockBookmark *mocks.MockBookmarks, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockBookmark.EXPECT().List(gomock.Any(), AuthorId, "", "", 20).Return(domain.BookmarkPage{}, domain.ErrInvalidReadingList)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid reading list name"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			bookmark := mocks.NewMockBookmarks(c)
+			test.mockBehavior(bookmark, auth)
+			handler := NewHandler(post, auth).WithBookmarks(bookmark)
+			// Init Endpoint
+			r := gin.Default()
+			r.GET("/users/me/bookmarks", handler.ListBookmarks)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/users/me/bookmarks"+test.query, nil)
+			req.AddCookie(test.mockCookie)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_AddBookmark(t *testing.T) {
+	type mockBehavior func(mockBookmark *mocks.MockBookmarks, mockUser *mocks.MockUsers)
+	var AuthorId int64 = 1
+
+	tests := []struct {
+		name                 string
+		inputPath            string
+		mockCookie           *http.Cookie
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputPath: "/users/me/bookmarks/3?list=golang",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockBookmark *mocks.MockBookmarks, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockBookmark.EXPECT().Add(gomock.Any(), AuthorId, int64(3), "golang").Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"bookmarked"}`,
+		},
+		{
+			name:      "Wrong input",
+			inputPath: "/users/me/bookmarks/wrong",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockBookmark *mocks.MockBookmarks, mockUser *mocks.MockUsers) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input post id"}`,
+		},
+		{
+			name:      "Post not found",
+			inputPath: "/users/me/bookmarks/3",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockBookmark *mocks.MockBookmarks, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockBookmark.EXPECT().Add(gomock.Any(), AuthorId, int64(3), "").Return(domain.ErrPostNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}`,
+		},
+		{
+			name:      "Service Error",
+			inputPath: "/users/me/bookmarks/3",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockBookmark *mocks.MockBookmarks, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockBookmark.EXPECT().Add(gomock.Any(), AuthorId, int64(3), "").Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			bookmark := mocks.NewMockBookmarks(c)
+			test.mockBehavior(bookmark, auth)
+			handler := NewHandler(post, auth).WithBookmarks(bookmark)
+			// Init Endpoint
+			r := gin.Default()
+			r.PUT("/users/me/bookmarks/:postId", handler.AddBookmark)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", test.inputPath, nil)
+			req.AddCookie(test.mockCookie)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"errors"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/gin-gonic/gin"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CacheStats godoc
+// @Summary Stats of the post cache
+// @Description Get the hits and misses of the post cache counted by the replica since it started, its evictions and size
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} domain.CacheStats
+// @Router /admin/cache/stats [get]
+func (h *Handler) CacheStats(c *gin.Context) {
+	stats, err := h.cacheService.Stats(c)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "CacheStats"}).Error(err)
+		c.JSON(http.StatusInternalServerError, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// LookupCacheKey godoc
+// @Summary Look up a key of the post cache
+// @Description Get the cache entry of a key, like post:1, as it is stored
+// @Tags admin
+// @Produce  json
+// @Param key path string true "Cache key"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} object
+// @Router /admin/cache/keys/{key} [get]
+func (h *Handler) LookupCacheKey(c *gin.Context) {
+	entry, err := h.cacheService.Lookup(c, c.Param("key"))
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "LookupCacheKey"}).Error(err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrCacheKeyNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", entry)
+}
+
+// FlushCacheKey godoc
+// @Summary Flush a key of the post cache
+// @Description Drop the cache entry of a key, the entries of the posts are dropped by all replicas
+// @Tags admin
+// @Produce  json
+// @Param key path string true "Cache key"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/cache/keys/{key} [delete]
+func (h *Handler) FlushCacheKey(c *gin.Context) {
+	if err := h.cacheService.FlushKey(c, c.Param("key")); err != nil {
+		log.WithFields(log.Fields{"handler": "FlushCacheKey"}).Error(err)
+		c.JSON(http.StatusInternalServerError, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "flushed",
+	})
+}
+
+// FlushCachePrefix godoc
+// @Summary Flush the keys of the post cache by prefix
+// @Description Drop the cache entries with the keys starting with the prefix, the in-process cache only of the replica serving the request
+// @Tags admin
+// @Produce  json
+// @Param prefix query string true "Key prefix, like post:"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/cache/keys [delete]
+func (h *Handler) FlushCachePrefix(c *gin.Context) {
+	prefix := c.Query("prefix")
+	if prefix == "" {
+		log.WithFields(log.Fields{"handler": "FlushCachePrefix"}).Error("empty prefix")
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "prefix is required",
+		})
+		return
+	}
+
+	flushed, err := h.cacheService.FlushPrefix(c, prefix)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "FlushCachePrefix"}).Error(err)
+		c.JSON(http.StatusInternalServerError, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"flushed": flushed,
+	})
+}
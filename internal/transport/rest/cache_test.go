@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/transport/rest/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_CacheAdmin(t *testing.T) {
+	type mockBehavior func(mockCache *mocks.MockCache, mockUser *mocks.MockUsers)
+	var AdminId int64 = 1
+
+	tests := []struct {
+		name                 string
+		method               string
+		path                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "Stats",
+			method: "GET",
+			path:   "/admin/cache/stats",
+			mockBehavior: func(mockCache *mocks.MockCache, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AdminId, nil)
+				mockCache.EXPECT().Stats(gomock.Any()).Return(domain.CacheStats{Hits: 7, Misses: 3, Evictions: 1, Size: 2}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"hits":7,"misses":3,"evictions":1,"size":2}`,
+		},
+		{
+			name:   "Not admin",
+			method: "GET",
+			path:   "/admin/cache/stats",
+			mockBehavior: func(mockCache *mocks.MockCache, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(int64(2), nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"forbidden"}`,
+		},
+		{
+			name:   "Expired token",
+			method: "DELETE",
+			path:   "/admin/cache/keys/post:1",
+			mockBehavior: func(mockCache *mocks.MockCache, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(int64(0), domain.ErrRefreshTokenExpired)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"refresh token expired"}`,
+		},
+		{
+			name:   "Lookup",
+			method: "GET",
+			path:   "/admin/cache/keys/post:1",
+			mockBehavior: func(mockCache *mocks.MockCache, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AdminId, nil)
+				mockCache.EXPECT().Lookup(gomock.Any(), "post:1").Return(json.RawMessage(`{"post":{"id":1}}`), nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"post":{"id":1}}`,
+		},
+		{
+			name:   "Lookup missing key",
+			method: "GET",
+			path:   "/admin/cache/keys/post:2",
+			mockBehavior: func(mockCache *mocks.MockCache, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AdminId, nil)
+				mockCache.EXPECT().Lookup(gomock.Any(), "post:2").Return(nil, domain.ErrCacheKeyNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"key is not cached"}`,
+		},
+		{
+			name:   "Flush key",
+			method: "DELETE",
+			path:   "/admin/cache/keys/post:1",
+			mockBehavior: func(mockCache *mocks.MockCache, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AdminId, nil)
+				mockCache.EXPECT().FlushKey(gomock.Any(), "post:1").Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"flushed"}`,
+		},
+		{
+			name:   "Flush prefix",
+			method: "DELETE",
+			path:   "/admin/cache/keys?prefix=post:",
+			mockBehavior: func(mockCache *mocks.MockCache, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AdminId, nil)
+				mockCache.EXPECT().FlushPrefix(gomock.Any(), "post:").Return(12, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"flushed":12}`,
+		},
+		{
+			name:   "Flush without prefix",
+			method: "DELETE",
+			path:   "/admin/cache/keys",
+			mockBehavior: func(mockCache *mocks.MockCache, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AdminId, nil)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"prefix is required"}`,
+		},
+		{
+			name:   "Service Failure",
+			method: "DELETE",
+			path:   "/admin/cache/keys?prefix=post:",
+			mockBehavior: func(mockCache *mocks.MockCache, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AdminId, nil)
+				mockCache.EXPECT().FlushPrefix(gomock.Any(), "post:").Return(0, errors.New("connection refused"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"connection refused"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			cache := mocks.NewMockCache(c)
+			test.mockBehavior(cache, auth)
+			handler := NewHandler(post, auth).WithCacheAdmin(cache, []int64{AdminId})
+			// Init Endpoint
+			r := gin.Default()
+			admin := r.Group("/admin", handler.adminMiddleware())
+			admin.GET("/cache/stats", handler.CacheStats)
+			admin.GET("/cache/keys/:key", handler.LookupCacheKey)
+			admin.DELETE("/cache/keys/:key", handler.FlushCacheKey)
+			admin.DELETE("/cache/keys", handler.FlushCachePrefix)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.path, nil)
+			req.AddCookie(&http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			})
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package rest
+
+import (
+	"errors"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/cursor"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCommentsLimit = 20
+	maxCommentsLimit     = 100
+)
+
+// ListComments godoc
+// @Summary Get comments of a post
+// @Description Get a page of root comments of a post with their reply threads
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param cursor query string false "Cursor of the next page"
+// @Param limit query int false "Root comments per page, 20 by default and 100 at most"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} domain.CommentPage
+// @Router /post/{id}/comments [get]
+func (h *Handler) ListComments(c *gin.Context) {
+	postId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "ListComments"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post id",
+		})
+		return
+	}
+	limit := defaultCommentsLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxCommentsLimit {
+			log.WithFields(log.Fields{"handler": "ListComments"}).Error("invalid limit ", value)
+			c.JSON(http.StatusBadRequest, map[string]string{
+				"message": "invalid limit",
+			})
+			return
+		}
+	}
+
+	page, err := h.commentsService.List(c, postId, c.Query("cursor"), limit)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "ListComments"}).Error(err)
+		commentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// CreateComment godoc
+// @Summary Comment a post
+// @Description Create a comment on a post or a reply to another comment of the post
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param comment body domain.CommentInput true "new comment"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 201 {object} domain.Comment
+// @Router /post/{id}/comments [post]
+func (h *Handler) CreateComment(c *gin.Context) {
+	postId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "CreateComment"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post id",
+		})
+		return
+	}
+	var inp domain.CommentInput
+	if err := c.BindJSON(&inp); err != nil || inp.Validate() != nil {
+		log.WithFields(log.Fields{"handler": "CreateComment"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input comment body",
+		})
+		return
+	}
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "CreateComment"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "CreateComment"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	comment, err := h.commentsService.Create(c, postId, inp, userId)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "CreateComment"}).Error(err)
+		commentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// UpdateComment godoc
+// @Summary Edit a comment
+// @Description Edit a comment of the post, only its author is allowed to
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param commentId path int true "Comment ID"
+// @Param comment body domain.UpdateComment true "comment"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} domain.Comment
+// @Router /post/{id}/comments/{commentId} [put]
+func (h *Handler) UpdateComment(c *gin.Context) {
+	postId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "UpdateComment"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post id",
+		})
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("commentId"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "UpdateComment"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input comment id",
+		})
+		return
+	}
+	var inp domain.UpdateComment
+	if err := c.BindJSON(&inp); err != nil || inp.Validate() != nil {
+		log.WithFields(log.Fields{"handler": "UpdateComment"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input comment body",
+		})
+		return
+	}
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "UpdateComment"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "UpdateComment"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	comment, err := h.commentsService.Update(c, postId, id, inp, userId)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "UpdateComment"}).Error(err)
+		commentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment godoc
+// @Summary Delete a comment
+// @Description Delete a comment of the post, allowed to its author and to the author of the post.
+// @Description Replies of the comment stay in the thread.
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param commentId path int true "Comment ID"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {string} string {"message": "deleted"}
+// @Router /post/{id}/comments/{commentId} [delete]
+func (h *Handler) DeleteComment(c *gin.Context) {
+	postId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "DeleteComment"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post id",
+		})
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("commentId"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "DeleteComment"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input comment id",
+		})
+		return
+	}
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "DeleteComment"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "DeleteComment"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.commentsService.Delete(c, postId, id, userId); err != nil {
+		log.WithFields(log.Fields{"handler": "DeleteComment"}).Error(err)
+		commentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "deleted",
+	})
+}
+
+// commentError maps the errors of the comments service to the response status.
+func commentError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, cursor.ErrInvalidCursor):
+		status = http.StatusBadRequest
+	case errors.Is(err, domain.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, domain.ErrPostNotFound), errors.Is(err, domain.ErrCommentNotFound):
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, map[string]string{
+		"message": err.Error(),
+	})
+}
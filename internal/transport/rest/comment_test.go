@@ -0,0 +1,369 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/transport/rest/mocks"
+	"github.com/Arkosh744/simpleREST_blog/pkg/cursor"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_ListComments(t *testing.T) {
+	type mockBehavior func(mockComment *mocks.MockComments)
+	var parentId int64 = 1
+	createdAt := time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		query                string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "Ok",
+			query: "",
+			mockBehavior: func(mockComment *mocks.MockComments) {
+				mockComment.EXPECT().List(gomock.Any(), int64(1), "", 20).Return(domain.CommentPage{
+					Comments: []domain.Comment{{
+						Id: 1, PostId: 1, AuthorId: 2, Body: "root", CreatedAt: createdAt, UpdatedAt: createdAt,
+						Replies: []domain.Comment{{
+							Id: 2, PostId: 1, ParentId: &parentId, AuthorId: 1, Body: "reply", CreatedAt: createdAt, UpdatedAt: createdAt,
+						}},
+					}},
+					NextCursor: "eyJpZCI6MX0",
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"comments":[{"id":1,"postId":1,"authorId":2,"body":"root",` +
+				`"createdAt":"2022-10-12T15:21:56Z","updatedAt":"2022-10-12T15:21:56Z",` +
+				`"replies":[{"id":2,"postId":1,"parentId":1,"authorId":1,"body":"reply",` +
+				`"createdAt":"2022-10-12T15:21:56Z","updatedAt":"2022-10-12T15:21:56Z"}]}],"nextCursor":"eyJpZCI6MX0"}`,
+		},
+		{
+			name:  "Next page",
+			query: "?cursor=eyJpZCI6MX0&limit=5",
+			mockBehavior: func(mockComment *mocks.MockComments) {
+				mockComment.EXPECT().List(gomock.Any(), int64(1), "eyJpZCI6MX0", 5).Return(domain.CommentPage{
+					Comments: []domain.Comment{},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"comments":[]}`,
+		},
+		{
+			name:                 "Wrong limit",
+			query:                "?limit=1000",
+			mockBehavior:         func(mockComment *mocks.MockComments) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid limit"}`,
+		},
+		{
+			name:  "Wrong cursor",
+			query: "?cursor=wrong",
+			mockBehavior: func(mockComment *mocks.MockComments) {
+				mockComment.EXPECT().List(gomock.Any(), int64(1), "wrong", 20).Return(domain.CommentPage{}, cursor.ErrInvalidCursor)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid cursor"}`,
+		},
+		{
+			name:  "Post not found",
+			query: "",
+			mockBehavior: func(mockComment *mocks.MockComments) {
+				mockComment.EXPECT().List(gomock.Any(), int64(1), "", 20).Return(domain.CommentPage{}, domain.ErrPostNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			comment := mocks.NewMockComments(c)
+			test.mockBehavior(comment)
+			handler := NewHandler(post, auth).WithComments(comment)
+			// Init Endpoint
+			r := gin.Default()
+			r.GET("/post/:id/comments", handler.ListComments)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/post/1/comments"+test.query, nil)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_CreateComment(t *testing.T) {
+	type mockBehavior func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers, ctx context.Context, inp domain.CommentInput)
+	var AuthorId int64 = 1
+	var parentId int64 = 3
+	createdAt := time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		inputComment         domain.CommentInput
+		mockCookie           *http.Cookie
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok",
+			inputBody:    `{"body": "TestBody", "parentId": 3}`,
+			inputComment: domain.CommentInput{Body: "TestBody", ParentId: &parentId},
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers, ctx context.Context, inp domain.CommentInput) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockComment.EXPECT().Create(gomock.Any(), int64(1), inp, AuthorId).Return(domain.Comment{
+					Id: 4, PostId: 1, ParentId: &parentId, AuthorId: AuthorId, Body: "TestBody", CreatedAt: createdAt, UpdatedAt: createdAt,
+				}, nil)
+			},
+			expectedStatusCode: 201,
+			expectedResponseBody: `{"id":4,"postId":1,"parentId":3,"authorId":1,"body":"TestBody",` +
+				`"createdAt":"2022-10-12T15:21:56Z","updatedAt":"2022-10-12T15:21:56Z"}`,
+		},
+		{
+			name:       "W/o Cookie",
+			inputBody:  `{"body": "TestBody"}`,
+			mockCookie: &http.Cookie{},
+			mockBehavior: func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers, ctx context.Context, inp domain.CommentInput) {
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"http: named cookie not present"}`,
+		},
+		{
+			name:      "Wrong input",
+			inputBody: `{"title": "TestTitle"}`,
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers, ctx context.Context, inp domain.CommentInput) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input comment body"}`,
+		},
+		{
+			name:         "Parent not found",
+			inputBody:    `{"body": "TestBody", "parentId": 3}`,
+			inputComment: domain.CommentInput{Body: "TestBody", ParentId: &parentId},
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers, ctx context.Context, inp domain.CommentInput) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockComment.EXPECT().Create(gomock.Any(), int64(1), inp, AuthorId).Return(domain.Comment{}, domain.ErrCommentNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"comment not found"}`,
+		},
+		{
+			name:         "Service Error",
+			inputBody:    `{"body": "TestBody"}`,
+			inputComment: domain.CommentInput{Body: "TestBody"},
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers, ctx context.Context, inp domain.CommentInput) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockComment.EXPECT().Create(gomock.Any(), int64(1), inp, AuthorId).Return(domain.Comment{}, errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			comment := mocks.NewMockComments(c)
+			test.mockBehavior(comment, auth, context.Background(), test.inputComment)
+			handler := NewHandler(post, auth).WithComments(comment)
+			// Init Endpoint
+			r := gin.Default()
+			r.POST("/post/:id/comments", handler.CreateComment)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/post/1/comments", bytes.NewBufferString(test.inputBody))
+			req.AddCookie(test.mockCookie)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_DeleteComment(t *testing.T) {
+	type mockBehavior func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers)
+	var AuthorId int64 = 1
+
+	tests := []struct {
+		name                 string
+		inputPostID          string
+		inputID              string
+		mockCookie           *http.Cookie
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok",
+			inputPostID: "1",
+			inputID:     "2",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockComment.EXPECT().Delete(gomock.Any(), int64(1), int64(2), AuthorId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"deleted"}`,
+		},
+		{
+			name:        "Wrong input",
+			inputPostID: "1",
+			inputID:     "wrong",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior:         func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input comment id"}`,
+		},
+		{
+			name:        "Not the author",
+			inputPostID: "1",
+			inputID:     "2",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockComment.EXPECT().Delete(gomock.Any(), int64(1), int64(2), AuthorId).Return(domain.ErrForbidden)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"forbidden"}`,
+		},
+		{
+			name:        "Not found",
+			inputPostID: "1",
+			inputID:     "2",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockComment.EXPECT().Delete(gomock.Any(), int64(1), int64(2), AuthorId).Return(domain.ErrCommentNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"comment not found"}`,
+		},
+		{
+			name:        "Comment of another post",
+			inputPostID: "999",
+			inputID:     "2",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockComment.EXPECT().Delete(gomock.Any(), int64(999), int64(2), AuthorId).Return(domain.ErrCommentNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"comment not found"}`,
+		},
+		{
+			name:        "Wrong post id",
+			inputPostID: "wrong",
+			inputID:     "2",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior:         func(mockComment *mocks.MockComments, mockUser *mocks.MockUsers) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input post id"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			comment := mocks.NewMockComments(c)
+			test.mockBehavior(comment, auth)
+			handler := NewHandler(post, auth).WithComments(comment)
+			// Init Endpoint
+			r := gin.Default()
+			r.DELETE("/post/:id/comments/:commentId", handler.DeleteComment)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", fmt.Sprintf("/post/%s/comments/%s", test.inputPostID, test.inputID), nil)
+			req.AddCookie(test.mockCookie)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
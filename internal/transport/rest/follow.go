@@ -0,0 +1,199 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/cursor"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+)
+
+// GetProfile godoc
+// @Summary Get a user profile
+// @Description Get the public profile of a user with the follow counters
+// @Tags follows
+// @Accept  json
+// @Produce  json
+// @Param id path int true "User ID"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} domain.Profile
+// @Router /users/{id} [get]
+func (h *Handler) GetProfile(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "GetProfile"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input user id",
+		})
+		return
+	}
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "GetProfile"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "GetProfile"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	profile, err := h.followsService.Profile(c, id, userId)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "GetProfile"}).Error(err)
+		followError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// Follow godoc
+// @Summary Follow an author
+// @Description Follow the posts of a user, following twice changes nothing
+// @Tags follows
+// @Accept  json
+// @Produce  json
+// @Param id path int true "User ID"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {string} string {"message": "followed"}
+// @Router /users/{id}/follow [put]
+func (h *Handler) Follow(c *gin.Context) {
+	h.changeFollow(c, "Follow", h.followsService.Follow, "followed")
+}
+
+// Unfollow godoc
+// @Summary Unfollow an author
+// @Description Stop following the posts of a user, unfollowing twice changes nothing
+// @Tags follows
+// @Accept  json
+// @Produce  json
+// @Param id path int true "User ID"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {string} string {"message": "unfollowed"}
+// @Router /users/{id}/follow [delete]
+func (h *Handler) Unfollow(c *gin.Context) {
+	h.changeFollow(c, "Unfollow", h.followsService.Unfollow, "unfollowed")
+}
+
+func (h *Handler) changeFollow(c *gin.Context, handler string,
+	change func(ctx context.Context, userId, authorId int64) error, message string) {
+	authorId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input user id",
+		})
+		return
+	}
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := change(c, userId, authorId); err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		followError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": message,
+	})
+}
+
+// Feed godoc
+// @Summary Get the home feed
+// @Description Get a page of posts of the followed authors, newest first
+// @Tags follows
+// @Accept  json
+// @Produce  json
+// @Param cursor query string false "Cursor of the next page"
+// @Param limit query int false "Posts per page, 20 by default and 100 at most"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} domain.FeedPage
+// @Router /feed [get]
+func (h *Handler) Feed(c *gin.Context) {
+	limit := defaultFeedLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxFeedLimit {
+			log.WithFields(log.Fields{"handler": "Feed"}).Error("invalid limit ", value)
+			c.JSON(http.StatusBadRequest, map[string]string{
+				"message": "invalid limit",
+			})
+			return
+		}
+	}
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "Feed"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "Feed"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	page, err := h.followsService.Feed(c, userId, c.Query("cursor"), limit)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "Feed"}).Error(err)
+		followError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// followError maps the errors of the follows service to the response status.
+func followError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, cursor.ErrInvalidCursor), errors.Is(err, domain.ErrFollowSelf):
+		status = http.StatusBadRequest
+	case errors.Is(err, domain.ErrUserNotFound):
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, map[string]string{
+		"message": err.Error(),
+	})
+}
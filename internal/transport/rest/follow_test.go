@@ -0,0 +1,227 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/transport/rest/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_Follow(t *testing.T) {
+	type mockBehavior func(mockFollow *mocks.MockFollows, mockUser *mocks.MockUsers)
+	var AuthorId int64 = 1
+
+	tests := []struct {
+		name                 string
+		method               string
+		inputID              string
+		mockCookie           *http.Cookie
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:    "Ok",
+			method:  "PUT",
+			inputID: "2",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockFollow *mocks.MockFollows, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockFollow.EXPECT().Follow(gomock.Any(), AuthorId, int64(2)).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"followed"}`,
+		},
+		{
+			name:    "Unfollow",
+			method:  "DELETE",
+			inputID: "2",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockFollow *mocks.MockFollows, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockFollow.EXPECT().Unfollow(gomock.Any(), AuthorId, int64(2)).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"unfollowed"}`,
+		},
+		{
+			name:    "Wrong input",
+			method:  "PUT",
+			inputID: "wrong",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockFollow *mocks.MockFollows, mockUser *mocks.MockUsers) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input user id"}`,
+		},
+		{
+			name:    "Follow self",
+			method:  "PUT",
+			inputID: "1",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockFollow *mocks.MockFollows, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockFollow.EXPECT().Follow(gomock.Any(), AuthorId, AuthorId).Return(domain.ErrFollowSelf)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"users can not follow themselves"}`,
+		},
+		{
+			name:    "User not found",
+			method:  "PUT",
+			inputID: "5",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockFollow *mocks.MockFollows, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockFollow.EXPECT().Follow(gomock.Any(), AuthorId, int64(5)).Return(domain.ErrUserNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"user not found"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			follow := mocks.NewMockFollows(c)
+			test.mockBehavior(follow, auth)
+			handler := NewHandler(post, auth).WithFollows(follow)
+			// Init Endpoint
+			r := gin.Default()
+			r.PUT("/users/:id/follow", handler.Follow)
+			r.DELETE("/users/:id/follow", handler.Unfollow)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, fmt.Sprintf("/users/%s/follow", test.inputID), nil)
+			req.AddCookie(test.mockCookie)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_Feed(t *testing.T) {
+	type mockBehavior func(mockFollow *mocks.MockFollows, mockUser *mocks.MockUsers)
+	var AuthorId int64 = 1
+	createdAt := time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		query                string
+		mockCookie           *http.Cookie
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "Ok",
+			query: "?cursor=abc&limit=1",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockFollow *mocks.MockFollows, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockFollow.EXPECT().Feed(gomock.Any(), AuthorId, "abc", 1).Return(domain.FeedPage{
+					Posts: []domain.Post{{
+						Id: 3, Title: "TestTitle", Body: "TestBody", AuthorId: 2, CreatedAt: createdAt, UpdatedAt: createdAt,
+					}},
+					NextCursor: "next",
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"posts":[{"id":3,"title":"TestTitle","body":"TestBody","AuthorId":2,` +
+				`"createdAt":"2022-10-12T15:21:56Z","updatedAt":"2022-10-12T15:21:56Z"}],"nextCursor":"next"}`,
+		},
+		{
+			name:       "W/o Cookie",
+			query:      "",
+			mockCookie: &http.Cookie{},
+			mockBehavior: func(mockFollow *mocks.MockFollows, mockUser *mocks.MockUsers) {
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"http: named cookie not present"}`,
+		},
+		{
+			name:  "Service Error",
+			query: "",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockFollow *mocks.MockFollows, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockFollow.EXPECT().Feed(gomock.Any(), AuthorId, "", 20).Return(domain.FeedPage{}, errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			follow := mocks.NewMockFollows(c)
+			test.mockBehavior(follow, auth)
+			handler := NewHandler(post, auth).WithFollows(follow)
+			// Init Endpoint
+			r := gin.Default()
+			r.GET("/feed", handler.Feed)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/feed"+test.query, nil)
+			req.AddCookie(test.mockCookie)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
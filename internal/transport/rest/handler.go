@@ -2,10 +2,14 @@ package rest
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/feed"
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
+	"io"
+	"net/http"
 
 	_ "github.com/Arkosh744/simpleREST_blog/docs"
 )
@@ -13,11 +17,65 @@ import (
 //go:generate mockgen -source=handler.go -destination=mocks/mocks.go -package=mocks
 
 type Posts interface {
-	Create(ctx context.Context, post domain.Post) error
+	Create(ctx context.Context, post domain.Post) (domain.Post, error)
 	GetById(ctx context.Context, id int64, userId int64) (domain.Post, error)
+	GetBySlug(ctx context.Context, slug string, userId int64) (domain.Post, error)
 	List(ctx context.Context, userId int64) ([]domain.Post, error)
 	Delete(ctx context.Context, id int64, userId int64) error
-	Update(ctx context.Context, id int64, post domain.UpdatePost, userId int64) error
+	Update(ctx context.Context, id int64, post domain.UpdatePost, userId int64) (domain.Post, error)
+	ListTrash(ctx context.Context, userId int64) ([]domain.Post, error)
+	Restore(ctx context.Context, id int64, userId int64) error
+}
+
+type Comments interface {
+	Create(ctx context.Context, postId int64, inp domain.CommentInput, userId int64) (domain.Comment, error)
+	List(ctx context.Context, postId int64, cursor string, limit int) (domain.CommentPage, error)
+	Update(ctx context.Context, postId int64, id int64, inp domain.UpdateComment, userId int64) (domain.Comment, error)
+	Delete(ctx context.Context, postId int64, id int64, userId int64) error
+}
+
+type Reactions interface {
+	React(ctx context.Context, postId int64, kind string, userId int64) (map[string]int, error)
+	Unreact(ctx context.Context, postId int64, kind string, userId int64) (map[string]int, error)
+}
+
+type Bookmarks interface {
+	Add(ctx context.Context, userId, postId int64, list string) error
+	Remove(ctx context.Context, userId, postId int64, list string) error
+	List(ctx context.Context, userId int64, list string, cursor string, limit int) (domain.BookmarkPage, error)
+	ReadingLists(ctx context.Context, userId int64) ([]domain.ReadingList, error)
+}
+
+type Follows interface {
+	Follow(ctx context.Context, userId, authorId int64) error
+	Unfollow(ctx context.Context, userId, authorId int64) error
+	Profile(ctx context.Context, id int64, userId int64) (domain.Profile, error)
+	Feed(ctx context.Context, userId int64, cursor string, limit int) (domain.FeedPage, error)
+}
+
+type Syndication interface {
+	BlogFeed(ctx context.Context, self string) (feed.Feed, error)
+	AuthorFeed(ctx context.Context, authorId int64, self string) (feed.Feed, error)
+}
+
+type Sitemap interface {
+	Write(ctx context.Context, w io.Writer) error
+	WritePage(ctx context.Context, w io.Writer, page int) error
+}
+
+type Media interface {
+	Upload(ctx context.Context, userId int64, r io.Reader) (domain.Media, error)
+	Open(ctx context.Context, id int64) (domain.Media, io.ReadCloser, error)
+	OpenVariant(ctx context.Context, id int64, name, mimeType string) (domain.MediaVariant, io.ReadCloser, error)
+	Attach(ctx context.Context, postId, mediaId, userId int64) error
+	Detach(ctx context.Context, postId, mediaId, userId int64) error
+}
+
+type Cache interface {
+	Stats(ctx context.Context) (domain.CacheStats, error)
+	Lookup(ctx context.Context, key string) (json.RawMessage, error)
+	FlushKey(ctx context.Context, key string) error
+	FlushPrefix(ctx context.Context, prefix string) (int, error)
 }
 
 type Users interface {
@@ -29,8 +87,20 @@ type Users interface {
 }
 
 type Handler struct {
-	postsService Posts
-	usersService Users
+	postsService     Posts
+	usersService     Users
+	commentsService  Comments
+	reactionsService Reactions
+	bookmarksService Bookmarks
+	followsService   Follows
+	syndication      Syndication
+	sitemap          Sitemap
+	mediaService     Media
+	mediaMaxSize     int64
+	cacheService     Cache
+	admins           map[int64]bool
+	metrics          http.Handler
+	postLimits       domain.PostLimits
 }
 
 func NewHandler(posts Posts, users Users) *Handler {
@@ -40,6 +110,72 @@ func NewHandler(posts Posts, users Users) *Handler {
 	}
 }
 
+// WithPostLimits enables the length validation of created and updated posts.
+func (h *Handler) WithPostLimits(limits domain.PostLimits) *Handler {
+	h.postLimits = limits
+	return h
+}
+
+// WithComments enables the comments routes of the posts.
+func (h *Handler) WithComments(comments Comments) *Handler {
+	h.commentsService = comments
+	return h
+}
+
+// WithReactions enables the reactions routes of the posts.
+func (h *Handler) WithReactions(reactions Reactions) *Handler {
+	h.reactionsService = reactions
+	return h
+}
+
+// WithBookmarks enables the bookmarks and reading lists routes of the users.
+func (h *Handler) WithBookmarks(bookmarks Bookmarks) *Handler {
+	h.bookmarksService = bookmarks
+	return h
+}
+
+// WithFollows enables the profiles, follows and the feed routes.
+func (h *Handler) WithFollows(follows Follows) *Handler {
+	h.followsService = follows
+	return h
+}
+
+// WithSyndication enables the public RSS and Atom feeds.
+func (h *Handler) WithSyndication(syndication Syndication) *Handler {
+	h.syndication = syndication
+	return h
+}
+
+// WithSitemap enables the public sitemap of the posts.
+func (h *Handler) WithSitemap(sitemap Sitemap) *Handler {
+	h.sitemap = sitemap
+	return h
+}
+
+// WithMedia enables the media uploads and the attachments of the posts,
+// uploads larger than maxSize bytes are rejected.
+func (h *Handler) WithMedia(media Media, maxSize int64) *Handler {
+	h.mediaService = media
+	h.mediaMaxSize = maxSize
+	return h
+}
+
+// WithCacheAdmin enables the admin routes of the post cache, only the users with the admins ids may use them.
+func (h *Handler) WithCacheAdmin(cache Cache, admins []int64) *Handler {
+	h.cacheService = cache
+	h.admins = make(map[int64]bool, len(admins))
+	for _, id := range admins {
+		h.admins[id] = true
+	}
+	return h
+}
+
+// WithMetrics exposes the metrics of the app for scraping at /metrics.
+func (h *Handler) WithMetrics(metrics http.Handler) *Handler {
+	h.metrics = metrics
+	return h
+}
+
 // @title Post Service REST API
 // @version 1.0
 // @description This is a simple crud blog for posts
@@ -57,14 +193,81 @@ func (h *Handler) InitRouter() *gin.Engine {
 		auth.POST("/sign-in", h.signIn)
 		auth.GET("/refresh", h.refresh)
 	}
+	// the responses of the authenticated routes are personalized, they are only kept by the browser of the user
+	private := cacheMiddleware(cachePrivate)
 	post := router.Group("/post")
 	{
 		post.Use(h.authMiddleware())
 		post.POST("", h.Create)
-		post.GET("", h.List)
-		post.GET("/:id", h.GetById)
-		post.PUT("", h.UpdateById)
-		post.DELETE("", h.DeleteById)
+		post.GET("", private, h.List)
+		post.GET("/trash", private, h.ListTrash)
+		post.GET("/:id", private, h.GetById)
+		post.GET("/by-slug/:slug", private, h.GetBySlug)
+		post.POST("/:id/restore", h.Restore)
+		post.PUT("/:id", h.ReplacePost)
+		post.PATCH("/:id", h.PatchPost)
+		post.DELETE("/:id", h.DeletePost)
+		// kept for one more version, these take the post id from the body
+		post.PUT("", deprecatedMiddleware("PUT /post/{id}"), h.UpdateById)
+		post.DELETE("", deprecatedMiddleware("DELETE /post/{id}"), h.DeleteById)
+		if h.commentsService != nil {
+			post.GET("/:id/comments", private, h.ListComments)
+			post.POST("/:id/comments", h.CreateComment)
+			post.PUT("/:id/comments/:commentId", h.UpdateComment)
+			post.DELETE("/:id/comments/:commentId", h.DeleteComment)
+		}
+		if h.reactionsService != nil {
+			post.PUT("/:id/reactions/:kind", h.React)
+			post.DELETE("/:id/reactions/:kind", h.Unreact)
+		}
+		if h.mediaService != nil {
+			post.PUT("/:id/media/:mediaId", h.AttachMedia)
+			post.DELETE("/:id/media/:mediaId", h.DetachMedia)
+		}
+	}
+	users := router.Group("/users")
+	{
+		users.Use(h.authMiddleware())
+		if h.bookmarksService != nil {
+			users.GET("/me/bookmarks", private, h.ListBookmarks)
+			users.PUT("/me/bookmarks/:postId", h.AddBookmark)
+			users.DELETE("/me/bookmarks/:postId", h.RemoveBookmark)
+			users.GET("/me/reading-lists", private, h.ListReadingLists)
+		}
+		if h.followsService != nil {
+			users.GET("/:id", private, h.GetProfile)
+			users.PUT("/:id/follow", h.Follow)
+			users.DELETE("/:id/follow", h.Unfollow)
+		}
+	}
+	if h.followsService != nil {
+		router.GET("/feed", h.authMiddleware(), private, h.Feed)
+	}
+	if h.syndication != nil {
+		router.GET("/posts/:slug", cacheMiddleware(cachePublic), h.GetPublicPost)
+		router.GET("/feed.rss", h.BlogRSS)
+		router.GET("/feed.atom", h.BlogAtom)
+		router.GET("/users/:id/feed.atom", h.AuthorAtom)
+	}
+	if h.sitemap != nil {
+		router.GET("/sitemap.xml", h.SitemapIndex)
+		router.GET("/sitemap/:page", h.SitemapPage)
+	}
+	if h.mediaService != nil {
+		router.POST("/media", h.authMiddleware(), h.UploadMedia)
+		router.GET("/media/:id", h.GetMedia)
+		router.GET("/media/:id/:variant", h.GetMediaVariant)
+	}
+	if h.cacheService != nil {
+		admin := router.Group("/admin")
+		admin.Use(h.authMiddleware(), h.adminMiddleware())
+		admin.GET("/cache/stats", h.CacheStats)
+		admin.GET("/cache/keys/:key", h.LookupCacheKey)
+		admin.DELETE("/cache/keys/:key", h.FlushCacheKey)
+		admin.DELETE("/cache/keys", h.FlushCachePrefix)
+	}
+	if h.metrics != nil {
+		router.GET("/metrics", gin.WrapH(h.metrics))
 	}
 	router.Use(loggerMiddleware())
 	return router
@@ -0,0 +1,270 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// multipartOverhead bounds the size of the multipart request besides the uploaded file.
+const multipartOverhead = 1 << 20
+
+// mediaCacheControl lets the clients keep the content forever, the content of a media or its variant never changes.
+const mediaCacheControl = "public, max-age=31536000, immutable"
+
+// UploadMedia godoc
+// @Summary Upload media
+// @Description Upload an image, its type is detected from the content and it is removed if no post references it for a while
+// @Tags media
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param file formData file true "Image, JPEG, PNG, GIF or WebP"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 201 {object} domain.Media
+// @Router /media [post]
+func (h *Handler) UploadMedia(c *gin.Context) {
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "UploadMedia"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "UploadMedia"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.mediaMaxSize+multipartOverhead)
+	parts, err := c.Request.MultipartReader()
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "UploadMedia"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid multipart form",
+		})
+		return
+	}
+	// the file is streamed to the service without buffering the whole form
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			c.JSON(http.StatusBadRequest, map[string]string{
+				"message": "missing file",
+			})
+			return
+		}
+		if err != nil {
+			log.WithFields(log.Fields{"handler": "UploadMedia"}).Error(err)
+			c.JSON(http.StatusBadRequest, map[string]string{
+				"message": "invalid multipart form",
+			})
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		media, err := h.mediaService.Upload(c, userId, part)
+		if err != nil {
+			log.WithFields(log.Fields{"handler": "UploadMedia"}).Error(err)
+			mediaError(c, err)
+			return
+		}
+
+		c.Header("Location", media.URL)
+		c.JSON(http.StatusCreated, media)
+		return
+	}
+}
+
+// GetMedia godoc
+// @Summary Get media content
+// @Description Get the content of an uploaded media
+// @Tags media
+// @Produce  image/jpeg,image/png,image/gif,image/webp
+// @Param id path int true "Media ID"
+// @Success 200 {file} file
+// @Success 304
+// @Router /media/{id} [get]
+func (h *Handler) GetMedia(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "GetMedia"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input media id",
+		})
+		return
+	}
+
+	media, content, err := h.mediaService.Open(c, id)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "GetMedia"}).Error(err)
+		mediaError(c, err)
+		return
+	}
+	defer content.Close()
+
+	serveMedia(c, media.Hash, media.MimeType, media.Size, content)
+}
+
+// GetMediaVariant godoc
+// @Summary Get a media variant
+// @Description Get a resized variant of an uploaded image, the variants of a media are listed in its variants
+// @Tags media
+// @Produce  image/jpeg
+// @Param id path int true "Media ID"
+// @Param variant path string true "Variant name with the extension of its type, e.g. medium.jpg"
+// @Success 200 {file} file
+// @Success 304
+// @Router /media/{id}/{variant} [get]
+func (h *Handler) GetMediaVariant(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "GetMediaVariant"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input media id",
+		})
+		return
+	}
+	name, mimeType, ok := domain.ParseMediaVariant(c.Param("variant"))
+	if !ok {
+		log.WithFields(log.Fields{"handler": "GetMediaVariant"}).Error("invalid variant ", c.Param("variant"))
+		c.JSON(http.StatusNotFound, map[string]string{
+			"message": domain.ErrMediaNotFound.Error(),
+		})
+		return
+	}
+
+	variant, content, err := h.mediaService.OpenVariant(c, id, name, mimeType)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "GetMediaVariant"}).Error(err)
+		mediaError(c, err)
+		return
+	}
+	defer content.Close()
+
+	serveMedia(c, variant.Hash, variant.MimeType, variant.Size, content)
+}
+
+// serveMedia responds with the content, its hash is a strong ETag that never changes for the address.
+func serveMedia(c *gin.Context, hash, mimeType string, size int64, content io.Reader) {
+	etag := `"` + hash + `"`
+	c.Header("Cache-Control", mediaCacheControl)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, size, mimeType, content, map[string]string{
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// AttachMedia godoc
+// @Summary Attach media to a post
+// @Description Reference an uploaded media of the current user from a post of the current user, attaching it twice changes nothing
+// @Tags media
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param mediaId path int true "Media ID"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {string} string {"message": "attached"}
+// @Router /post/{id}/media/{mediaId} [put]
+func (h *Handler) AttachMedia(c *gin.Context) {
+	h.changeAttachment(c, "AttachMedia", h.mediaService.Attach, "attached")
+}
+
+// DetachMedia godoc
+// @Summary Detach media from a post
+// @Description Drop the reference to a media from a post of the current user, detaching a missing one changes nothing
+// @Tags media
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param mediaId path int true "Media ID"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {string} string {"message": "detached"}
+// @Router /post/{id}/media/{mediaId} [delete]
+func (h *Handler) DetachMedia(c *gin.Context) {
+	h.changeAttachment(c, "DetachMedia", h.mediaService.Detach, "detached")
+}
+
+func (h *Handler) changeAttachment(c *gin.Context, handler string,
+	change func(ctx context.Context, postId, mediaId, userId int64) error, message string) {
+	postId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post id",
+		})
+		return
+	}
+	mediaId, err := strconv.ParseInt(c.Param("mediaId"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input media id",
+		})
+		return
+	}
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := change(c, postId, mediaId, userId); err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		mediaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": message,
+	})
+}
+
+// mediaError maps the errors of the media service to the response status.
+func mediaError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrMediaTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrUnsupportedMedia):
+		status = http.StatusUnsupportedMediaType
+	case errors.Is(err, domain.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, domain.ErrPostNotFound), errors.Is(err, domain.ErrMediaNotFound):
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, map[string]string{
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,444 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/transport/rest/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_UploadMedia(t *testing.T) {
+	type mockBehavior func(mockMedia *mocks.MockMedia, mockUser *mocks.MockUsers)
+	var AuthorId int64 = 1
+	createdAt := time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		field                string
+		mockCookie           *http.Cookie
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "Ok",
+			field: "file",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockMedia *mocks.MockMedia, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockMedia.EXPECT().Upload(gomock.Any(), AuthorId, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, userId int64, r io.Reader) (domain.Media, error) {
+						content, err := io.ReadAll(r)
+						if err != nil || string(content) != "image" {
+							return domain.Media{}, fmt.Errorf("unexpected content %q: %v", content, err)
+						}
+						return domain.Media{Id: 7, OwnerId: userId, Hash: "abc", MimeType: "image/png", Size: 5,
+							URL: "/media/7", CreatedAt: createdAt}, nil
+					})
+			},
+			expectedStatusCode: 201,
+			expectedResponseBody: `{"id":7,"ownerId":1,"hash":"abc","mimeType":"image/png","size":5,"url":"/media/7",` +
+				`"createdAt":"2022-10-12T15:21:56Z"}`,
+		},
+		{
+			name:       "W/o Cookie",
+			field:      "file",
+			mockCookie: &http.Cookie{},
+			mockBehavior: func(mockMedia *mocks.MockMedia, mockUser *mocks.MockUsers) {
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"http: named cookie not present"}`,
+		},
+		{
+			name:  "Missing file",
+			field: "image",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockMedia *mocks.MockMedia, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"missing file"}`,
+		},
+		{
+			name:  "Too large",
+			field: "file",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockMedia *mocks.MockMedia, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockMedia.EXPECT().Upload(gomock.Any(), AuthorId, gomock.Any()).Return(domain.Media{}, domain.ErrMediaTooLarge)
+			},
+			expectedStatusCode:   413,
+			expectedResponseBody: `{"message":"media is too large"}`,
+		},
+		{
+			name:  "Unsupported type",
+			field: "file",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockMedia *mocks.MockMedia, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockMedia.EXPECT().Upload(gomock.Any(), AuthorId, gomock.Any()).Return(domain.Media{}, domain.ErrUnsupportedMedia)
+			},
+			expectedStatusCode:   415,
+			expectedResponseBody: `{"message":"unsupported media type"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			media := mocks.NewMockMedia(c)
+			test.mockBehavior(media, auth)
+			handler := NewHandler(post, auth).WithMedia(media, 1<<20)
+			// Init Endpoint
+			r := gin.Default()
+			r.POST("/media", handler.UploadMedia)
+
+			// Create Request
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			_ = form.WriteField("title", "gopher")
+			file, _ := form.CreateFormFile(test.field, "gopher.png")
+			_, _ = file.Write([]byte("image"))
+			_ = form.Close()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/media", &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			req.AddCookie(test.mockCookie)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_GetMedia(t *testing.T) {
+	type mockBehavior func(mockMedia *mocks.MockMedia)
+	media := domain.Media{Id: 7, Hash: "abc", MimeType: "image/png", Size: 5}
+
+	tests := []struct {
+		name                 string
+		inputId              string
+		ifNoneMatch          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+		expectedType         string
+	}{
+		{
+			name:    "Ok",
+			inputId: "7",
+			mockBehavior: func(mockMedia *mocks.MockMedia) {
+				mockMedia.EXPECT().Open(gomock.Any(), int64(7)).Return(media, io.NopCloser(strings.NewReader("image")), nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "image",
+			expectedType:         "image/png",
+		},
+		{
+			name:        "Not modified",
+			inputId:     "7",
+			ifNoneMatch: `"abc"`,
+			mockBehavior: func(mockMedia *mocks.MockMedia) {
+				mockMedia.EXPECT().Open(gomock.Any(), int64(7)).Return(media, io.NopCloser(strings.NewReader("image")), nil)
+			},
+			expectedStatusCode:   304,
+			expectedResponseBody: "",
+		},
+		{
+			name:    "Wrong id",
+			inputId: "seven",
+			mockBehavior: func(mockMedia *mocks.MockMedia) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input media id"}`,
+			expectedType:         "application/json; charset=utf-8",
+		},
+		{
+			name:    "Not found",
+			inputId: "8",
+			mockBehavior: func(mockMedia *mocks.MockMedia) {
+				mockMedia.EXPECT().Open(gomock.Any(), int64(8)).Return(domain.Media{}, nil, domain.ErrMediaNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"media not found"}`,
+			expectedType:         "application/json; charset=utf-8",
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			mediaService := mocks.NewMockMedia(c)
+			test.mockBehavior(mediaService)
+			handler := NewHandler(post, auth).WithMedia(mediaService, 1<<20)
+			// Init Endpoint
+			r := gin.Default()
+			r.GET("/media/:id", handler.GetMedia)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/media/"+test.inputId, nil)
+			if test.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", test.ifNoneMatch)
+			}
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+			assert.Equal(t, w.Header().Get("Content-Type"), test.expectedType)
+			if test.expectedStatusCode == 200 || test.expectedStatusCode == 304 {
+				assert.Equal(t, w.Header().Get("Cache-Control"), "public, max-age=31536000, immutable")
+				assert.Equal(t, w.Header().Get("ETag"), `"abc"`)
+			}
+		})
+	}
+}
+
+func TestHandler_AttachMedia(t *testing.T) {
+	type mockBehavior func(mockMedia *mocks.MockMedia, mockUser *mocks.MockUsers)
+	var AuthorId int64 = 1
+
+	tests := []struct {
+		name                 string
+		method               string
+		mockCookie           *http.Cookie
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "Ok",
+			method: "PUT",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockMedia *mocks.MockMedia, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockMedia.EXPECT().Attach(gomock.Any(), int64(1), int64(7), AuthorId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"attached"}`,
+		},
+		{
+			name:   "Detach",
+			method: "DELETE",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockMedia *mocks.MockMedia, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockMedia.EXPECT().Detach(gomock.Any(), int64(1), int64(7), AuthorId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"detached"}`,
+		},
+		{
+			name:   "Not the author",
+			method: "PUT",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockMedia *mocks.MockMedia, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockMedia.EXPECT().Attach(gomock.Any(), int64(1), int64(7), AuthorId).Return(domain.ErrForbidden)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"forbidden"}`,
+		},
+		{
+			name:   "Media not found",
+			method: "PUT",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockMedia *mocks.MockMedia, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockMedia.EXPECT().Attach(gomock.Any(), int64(1), int64(7), AuthorId).Return(domain.ErrMediaNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"media not found"}`,
+		},
+		{
+			name:   "Service Error",
+			method: "DELETE",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockMedia *mocks.MockMedia, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockMedia.EXPECT().Detach(gomock.Any(), int64(1), int64(7), AuthorId).Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			media := mocks.NewMockMedia(c)
+			test.mockBehavior(media, auth)
+			handler := NewHandler(post, auth).WithMedia(media, 1<<20)
+			// Init Endpoint
+			r := gin.Default()
+			r.PUT("/post/:id/media/:mediaId", handler.AttachMedia)
+			r.DELETE("/post/:id/media/:mediaId", handler.DetachMedia)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/post/1/media/7", nil)
+			req.AddCookie(test.mockCookie)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_GetMediaVariant(t *testing.T) {
+	type mockBehavior func(mockMedia *mocks.MockMedia)
+
+	tests := []struct {
+		name                 string
+		inputVariant         string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+		expectedType         string
+	}{
+		{
+			name:         "JPEG",
+			inputVariant: "medium.jpg",
+			mockBehavior: func(mockMedia *mocks.MockMedia) {
+				variant := domain.MediaVariant{Name: "medium", MimeType: "image/jpeg", Width: 640, Height: 480, Hash: "def", Size: 4}
+				mockMedia.EXPECT().OpenVariant(gomock.Any(), int64(7), "medium", "image/jpeg").
+					Return(variant, io.NopCloser(strings.NewReader("jpeg")), nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "jpeg",
+			expectedType:         "image/jpeg",
+		},
+		{
+			name:         "WebP",
+			inputVariant: "medium.webp",
+			mockBehavior: func(mockMedia *mocks.MockMedia) {
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"media not found"}`,
+			expectedType:         "application/json; charset=utf-8",
+		},
+		{
+			name:         "Unknown type",
+			inputVariant: "medium.bmp",
+			mockBehavior: func(mockMedia *mocks.MockMedia) {
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"media not found"}`,
+			expectedType:         "application/json; charset=utf-8",
+		},
+		{
+			name:         "Not generated yet",
+			inputVariant: "large.jpg",
+			mockBehavior: func(mockMedia *mocks.MockMedia) {
+				mockMedia.EXPECT().OpenVariant(gomock.Any(), int64(7), "large", "image/jpeg").
+					Return(domain.MediaVariant{}, nil, domain.ErrMediaNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"media not found"}`,
+			expectedType:         "application/json; charset=utf-8",
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			mediaService := mocks.NewMockMedia(c)
+			test.mockBehavior(mediaService)
+			handler := NewHandler(post, auth).WithMedia(mediaService, 1<<20)
+			// Init Endpoint
+			r := gin.Default()
+			r.GET("/media/:id/:variant", handler.GetMediaVariant)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/media/7/"+test.inputVariant, nil)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+			assert.Equal(t, w.Header().Get("Content-Type"), test.expectedType)
+			if test.expectedStatusCode == 200 {
+				assert.Equal(t, w.Header().Get("Cache-Control"), "public, max-age=31536000, immutable")
+				assert.Equal(t, w.Header().Get("ETag"), `"def"`)
+			}
+		})
+	}
+}
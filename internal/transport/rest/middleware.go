@@ -1,7 +1,9 @@
 package rest
 
 import (
+	"bytes"
 	"errors"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"net/http"
@@ -9,6 +11,15 @@ import (
 	"time"
 )
 
+// The Cache-Control policies of the routes, see cacheMiddleware.
+const (
+	// cachePrivate keeps the responses for the user who asked for them and has them revalidated on every use,
+	// they depend on the user and change with every reaction or comment
+	cachePrivate = "private, no-cache"
+	// cachePublic lets the browsers and the CDNs share the published content for a while
+	cachePublic = "public, max-age=300"
+)
+
 type CtxValue int
 
 const (
@@ -33,6 +44,109 @@ func loggerMiddleware() gin.HandlerFunc {
 	}
 }
 
+// deprecatedMiddleware marks the responses of a route that is kept for one more version, successor names its replacement.
+func deprecatedMiddleware(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Warning", `299 - "Deprecated API, use `+successor+` instead"`)
+		c.Next()
+	}
+}
+
+// cacheMiddleware makes the successful GET responses of the route conditional. The response gets a strong ETag of
+// its body and policy as Cache-Control, unless the handler chose its own, and it is answered with 304 Not Modified
+// when If-None-Match or If-Modified-Since shows that the client already has it.
+func cacheMiddleware(policy string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.status != http.StatusOK {
+			w.flush(w.status)
+			return
+		}
+		if c.IsAborted() {
+			// the handler gave up in the middle of the response, nothing of it was sent yet
+			w.body.Reset()
+			c.JSON(http.StatusInternalServerError, map[string]string{
+				"message": "failed to write response",
+			})
+			return
+		}
+
+		header := w.Header()
+		etag := etagOf(w.body.Bytes())
+		if own := header.Get("ETag"); strings.HasPrefix(own, `"`) {
+			// the tag of the handler only covers what it versions, like the post version, the hash covers the rest
+			etag = strings.TrimSuffix(own, `"`) + "-" + strings.TrimPrefix(etag, `"`)
+		}
+		header.Set("ETag", etag)
+		if header.Get("Cache-Control") == "" {
+			header.Set("Cache-Control", policy)
+		}
+
+		lastModified, _ := http.ParseTime(header.Get("Last-Modified"))
+		if notModified(c.Request, etag, lastModified) {
+			header.Del("Content-Type")
+			w.body.Reset()
+			w.flush(http.StatusNotModified)
+			return
+		}
+		w.flush(http.StatusOK)
+	}
+}
+
+// bufferedWriter holds the response back until the handler is done, so it can be checked against the request.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+// flush sends the held back response with the status.
+func (w *bufferedWriter) flush(status int) {
+	w.ResponseWriter.WriteHeader(status)
+	w.ResponseWriter.WriteHeaderNow()
+	if w.body.Len() > 0 {
+		if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+			log.WithFields(log.Fields{"middleware": "cache"}).Error(err)
+		}
+	}
+}
+
 func (h *Handler) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token, err := getTokenFromContex(c)
@@ -57,6 +171,36 @@ func (h *Handler) authMiddleware() gin.HandlerFunc {
 	}
 }
 
+// adminMiddleware lets only the admins through, the user is identified by the refresh token like in the handlers.
+func (h *Handler) adminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cookie, err := c.Cookie("refresh-token")
+		if err != nil {
+			log.WithFields(log.Fields{"middleware": "admin"}).Error(err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{
+				"message": err.Error(),
+			})
+			return
+		}
+		userId, err := h.usersService.GetIdByToken(c, cookie)
+		if err != nil {
+			log.WithFields(log.Fields{"middleware": "admin"}).Error(err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{
+				"message": err.Error(),
+			})
+			return
+		}
+		if !h.admins[userId] {
+			log.WithFields(log.Fields{"middleware": "admin"}).Error("user ", userId, " is not an admin")
+			c.AbortWithStatusJSON(http.StatusForbidden, map[string]string{
+				"message": domain.ErrForbidden.Error(),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
 func getTokenFromContex(c *gin.Context) (string, error) {
 	header := c.Request.Header["Authorization"]
 	if len(header) == 0 {
@@ -0,0 +1,144 @@
+package rest
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/magiconair/properties/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCacheMiddleware(t *testing.T) {
+	body := `{"id":1,"title":"TestTitle"}`
+	etag := etagOf([]byte(body))
+
+	tests := []struct {
+		name                 string
+		handler              gin.HandlerFunc
+		headers              map[string]string
+		expectedStatusCode   int
+		expectedETag         string
+		expectedCacheControl string
+		expectedResponseBody string
+	}{
+		{
+			name: "Ok",
+			handler: func(c *gin.Context) {
+				c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(body))
+			},
+			expectedStatusCode:   200,
+			expectedETag:         etag,
+			expectedCacheControl: cachePrivate,
+			expectedResponseBody: body,
+		},
+		{
+			name: "Same ETag",
+			handler: func(c *gin.Context) {
+				c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(body))
+			},
+			headers:              map[string]string{"If-None-Match": `"old", ` + etag},
+			expectedStatusCode:   304,
+			expectedETag:         etag,
+			expectedCacheControl: cachePrivate,
+		},
+		{
+			name: "Changed ETag",
+			handler: func(c *gin.Context) {
+				c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(body))
+			},
+			headers:              map[string]string{"If-None-Match": `"old"`, "If-Modified-Since": "Thu, 13 Oct 2022 09:00:00 GMT"},
+			expectedStatusCode:   200,
+			expectedETag:         etag,
+			expectedCacheControl: cachePrivate,
+			expectedResponseBody: body,
+		},
+		{
+			name: "ETag of handler",
+			handler: func(c *gin.Context) {
+				c.Header("ETag", `"2"`)
+				c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(body))
+			},
+			headers:              map[string]string{"If-None-Match": `"2"`},
+			expectedStatusCode:   200,
+			expectedETag:         `"2-` + etag[1:],
+			expectedCacheControl: cachePrivate,
+			expectedResponseBody: body,
+		},
+		{
+			name: "Not modified since",
+			handler: func(c *gin.Context) {
+				c.Header("Last-Modified", "Thu, 13 Oct 2022 09:00:00 GMT")
+				c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(body))
+			},
+			headers:              map[string]string{"If-Modified-Since": "Thu, 13 Oct 2022 09:00:00 GMT"},
+			expectedStatusCode:   304,
+			expectedETag:         etag,
+			expectedCacheControl: cachePrivate,
+		},
+		{
+			name: "Modified since",
+			handler: func(c *gin.Context) {
+				c.Header("Last-Modified", "Thu, 13 Oct 2022 09:00:01 GMT")
+				c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(body))
+			},
+			headers:              map[string]string{"If-Modified-Since": "Thu, 13 Oct 2022 09:00:00 GMT"},
+			expectedStatusCode:   200,
+			expectedETag:         etag,
+			expectedCacheControl: cachePrivate,
+			expectedResponseBody: body,
+		},
+		{
+			name: "Cache-Control of handler",
+			handler: func(c *gin.Context) {
+				c.Header("Cache-Control", "no-store")
+				c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(body))
+			},
+			expectedStatusCode:   200,
+			expectedETag:         etag,
+			expectedCacheControl: "no-store",
+			expectedResponseBody: body,
+		},
+		{
+			name: "Error",
+			handler: func(c *gin.Context) {
+				c.JSON(http.StatusNotFound, map[string]string{"message": "post not found"})
+			},
+			headers:              map[string]string{"If-None-Match": "*"},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}`,
+		},
+		{
+			name: "Aborted",
+			handler: func(c *gin.Context) {
+				c.Data(http.StatusOK, "application/xml; charset=utf-8", []byte("<urlset>"))
+				c.Abort()
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"failed to write response"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Endpoint
+			r := gin.Default()
+			r.GET("/post/:id", cacheMiddleware(cachePrivate), test.handler)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/post/1", nil)
+			for key, value := range test.headers {
+				req.Header.Set(key, value)
+			}
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), test.expectedETag)
+			assert.Equal(t, w.Header().Get("Cache-Control"), test.expectedCacheControl)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
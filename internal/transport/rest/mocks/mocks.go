@@ -6,9 +6,12 @@ package mocks
 
 import (
 	context "context"
+	json "encoding/json"
+	io "io"
 	reflect "reflect"
 
 	domain "github.com/Arkosh744/simpleREST_blog/internal/domain"
+	feed "github.com/Arkosh744/simpleREST_blog/pkg/feed"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -36,11 +39,12 @@ func (m *MockPosts) EXPECT() *MockPostsMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockPosts) Create(ctx context.Context, post domain.Post) error {
+func (m *MockPosts) Create(ctx context.Context, post domain.Post) (domain.Post, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", ctx, post)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(domain.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
@@ -78,6 +82,21 @@ func (mr *MockPostsMockRecorder) GetById(ctx, id, userId interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockPosts)(nil).GetById), ctx, id, userId)
 }
 
+// GetBySlug mocks base method.
+func (m *MockPosts) GetBySlug(ctx context.Context, slug string, userId int64) (domain.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBySlug", ctx, slug, userId)
+	ret0, _ := ret[0].(domain.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBySlug indicates an expected call of GetBySlug.
+func (mr *MockPostsMockRecorder) GetBySlug(ctx, slug, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBySlug", reflect.TypeOf((*MockPosts)(nil).GetBySlug), ctx, slug, userId)
+}
+
 // List mocks base method.
 func (m *MockPosts) List(ctx context.Context, userId int64) ([]domain.Post, error) {
 	m.ctrl.T.Helper()
@@ -93,20 +112,631 @@ func (mr *MockPostsMockRecorder) List(ctx, userId interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockPosts)(nil).List), ctx, userId)
 }
 
-// Update mocks base method.
-func (m *MockPosts) Update(ctx context.Context, id int64, post domain.UpdatePost, userId int64) error {
+// ListTrash mocks base method.
+func (m *MockPosts) ListTrash(ctx context.Context, userId int64) ([]domain.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, id, post, userId)
+	ret := m.ctrl.Call(m, "ListTrash", ctx, userId)
+	ret0, _ := ret[0].([]domain.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrash indicates an expected call of ListTrash.
+func (mr *MockPostsMockRecorder) ListTrash(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrash", reflect.TypeOf((*MockPosts)(nil).ListTrash), ctx, userId)
+}
+
+// Restore mocks base method.
+func (m *MockPosts) Restore(ctx context.Context, id, userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id, userId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// Restore indicates an expected call of Restore.
+func (mr *MockPostsMockRecorder) Restore(ctx, id, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockPosts)(nil).Restore), ctx, id, userId)
+}
+
+// Update mocks base method.
+func (m *MockPosts) Update(ctx context.Context, id int64, post domain.UpdatePost, userId int64) (domain.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, id, post, userId)
+	ret0, _ := ret[0].(domain.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // Update indicates an expected call of Update.
 func (mr *MockPostsMockRecorder) Update(ctx, id, post, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPosts)(nil).Update), ctx, id, post, userId)
 }
 
+// MockComments is a mock of Comments interface.
+type MockComments struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommentsMockRecorder
+}
+
+// MockCommentsMockRecorder is the mock recorder for MockComments.
+type MockCommentsMockRecorder struct {
+	mock *MockComments
+}
+
+// NewMockComments creates a new mock instance.
+func NewMockComments(ctrl *gomock.Controller) *MockComments {
+	mock := &MockComments{ctrl: ctrl}
+	mock.recorder = &MockCommentsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockComments) EXPECT() *MockCommentsMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockComments) Create(ctx context.Context, postId int64, inp domain.CommentInput, userId int64) (domain.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, postId, inp, userId)
+	ret0, _ := ret[0].(domain.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockCommentsMockRecorder) Create(ctx, postId, inp, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockComments)(nil).Create), ctx, postId, inp, userId)
+}
+
+// Delete mocks base method.
+func (m *MockComments) Delete(ctx context.Context, postId, id, userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, postId, id, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCommentsMockRecorder) Delete(ctx, postId, id, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockComments)(nil).Delete), ctx, postId, id, userId)
+}
+
+// List mocks base method.
+func (m *MockComments) List(ctx context.Context, postId int64, cursor string, limit int) (domain.CommentPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, postId, cursor, limit)
+	ret0, _ := ret[0].(domain.CommentPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockCommentsMockRecorder) List(ctx, postId, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockComments)(nil).List), ctx, postId, cursor, limit)
+}
+
+// Update mocks base method.
+func (m *MockComments) Update(ctx context.Context, postId, id int64, inp domain.UpdateComment, userId int64) (domain.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, postId, id, inp, userId)
+	ret0, _ := ret[0].(domain.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockCommentsMockRecorder) Update(ctx, postId, id, inp, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockComments)(nil).Update), ctx, postId, id, inp, userId)
+}
+
+// MockReactions is a mock of Reactions interface.
+type MockReactions struct {
+	ctrl     *gomock.Controller
+	recorder *MockReactionsMockRecorder
+}
+
+// MockReactionsMockRecorder is the mock recorder for MockReactions.
+type MockReactionsMockRecorder struct {
+	mock *MockReactions
+}
+
+// NewMockReactions creates a new mock instance.
+func NewMockReactions(ctrl *gomock.Controller) *MockReactions {
+	mock := &MockReactions{ctrl: ctrl}
+	mock.recorder = &MockReactionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReactions) EXPECT() *MockReactionsMockRecorder {
+	return m.recorder
+}
+
+// React mocks base method.
+func (m *MockReactions) React(ctx context.Context, postId int64, kind string, userId int64) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "React", ctx, postId, kind, userId)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// React indicates an expected call of React.
+func (mr *MockReactionsMockRecorder) React(ctx, postId, kind, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "React", reflect.TypeOf((*MockReactions)(nil).React), ctx, postId, kind, userId)
+}
+
+// Unreact mocks base method.
+func (m *MockReactions) Unreact(ctx context.Context, postId int64, kind string, userId int64) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unreact", ctx, postId, kind, userId)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Unreact indicates an expected call of Unreact.
+func (mr *MockReactionsMockRecorder) Unreact(ctx, postId, kind, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unreact", reflect.TypeOf((*MockReactions)(nil).Unreact), ctx, postId, kind, userId)
+}
+
+// MockBookmarks is a mock of Bookmarks interface.
+type MockBookmarks struct {
+	ctrl     *gomock.Controller
+	recorder *MockBookmarksMockRecorder
+}
+
+// MockBookmarksMockRecorder is the mock recorder for MockBookmarks.
+type MockBookmarksMockRecorder struct {
+	mock *MockBookmarks
+}
+
+// NewMockBookmarks creates a new mock instance.
+func NewMockBookmarks(ctrl *gomock.Controller) *MockBookmarks {
+	mock := &MockBookmarks{ctrl: ctrl}
+	mock.recorder = &MockBookmarksMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBookmarks) EXPECT() *MockBookmarksMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockBookmarks) Add(ctx context.Context, userId, postId int64, list string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, userId, postId, list)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockBookmarksMockRecorder) Add(ctx, userId, postId, list interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockBookmarks)(nil).Add), ctx, userId, postId, list)
+}
+
+// List mocks base method.
+func (m *MockBookmarks) List(ctx context.Context, userId int64, list, cursor string, limit int) (domain.BookmarkPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, userId, list, cursor, limit)
+	ret0, _ := ret[0].(domain.BookmarkPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockBookmarksMockRecorder) List(ctx, userId, list, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockBookmarks)(nil).List), ctx, userId, list, cursor, limit)
+}
+
+// ReadingLists mocks base method.
+func (m *MockBookmarks) ReadingLists(ctx context.Context, userId int64) ([]domain.ReadingList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadingLists", ctx, userId)
+	ret0, _ := ret[0].([]domain.ReadingList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadingLists indicates an expected call of ReadingLists.
+func (mr *MockBookmarksMockRecorder) ReadingLists(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadingLists", reflect.TypeOf((*MockBookmarks)(nil).ReadingLists), ctx, userId)
+}
+
+// Remove mocks base method.
+func (m *MockBookmarks) Remove(ctx context.Context, userId, postId int64, list string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", ctx, userId, postId, list)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockBookmarksMockRecorder) Remove(ctx, userId, postId, list interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockBookmarks)(nil).Remove), ctx, userId, postId, list)
+}
+
+// MockFollows is a mock of Follows interface.
+type MockFollows struct {
+	ctrl     *gomock.Controller
+	recorder *MockFollowsMockRecorder
+}
+
+// MockFollowsMockRecorder is the mock recorder for MockFollows.
+type MockFollowsMockRecorder struct {
+	mock *MockFollows
+}
+
+// NewMockFollows creates a new mock instance.
+func NewMockFollows(ctrl *gomock.Controller) *MockFollows {
+	mock := &MockFollows{ctrl: ctrl}
+	mock.recorder = &MockFollowsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFollows) EXPECT() *MockFollowsMockRecorder {
+	return m.recorder
+}
+
+// Feed mocks base method.
+func (m *MockFollows) Feed(ctx context.Context, userId int64, cursor string, limit int) (domain.FeedPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Feed", ctx, userId, cursor, limit)
+	ret0, _ := ret[0].(domain.FeedPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Feed indicates an expected call of Feed.
+func (mr *MockFollowsMockRecorder) Feed(ctx, userId, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Feed", reflect.TypeOf((*MockFollows)(nil).Feed), ctx, userId, cursor, limit)
+}
+
+// Follow mocks base method.
+func (m *MockFollows) Follow(ctx context.Context, userId, authorId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Follow", ctx, userId, authorId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Follow indicates an expected call of Follow.
+func (mr *MockFollowsMockRecorder) Follow(ctx, userId, authorId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Follow", reflect.TypeOf((*MockFollows)(nil).Follow), ctx, userId, authorId)
+}
+
+// Profile mocks base method.
+func (m *MockFollows) Profile(ctx context.Context, id, userId int64) (domain.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Profile", ctx, id, userId)
+	ret0, _ := ret[0].(domain.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Profile indicates an expected call of Profile.
+func (mr *MockFollowsMockRecorder) Profile(ctx, id, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Profile", reflect.TypeOf((*MockFollows)(nil).Profile), ctx, id, userId)
+}
+
+// Unfollow mocks base method.
+func (m *MockFollows) Unfollow(ctx context.Context, userId, authorId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unfollow", ctx, userId, authorId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unfollow indicates an expected call of Unfollow.
+func (mr *MockFollowsMockRecorder) Unfollow(ctx, userId, authorId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unfollow", reflect.TypeOf((*MockFollows)(nil).Unfollow), ctx, userId, authorId)
+}
+
+// MockSyndication is a mock of Syndication interface.
+type MockSyndication struct {
+	ctrl     *gomock.Controller
+	recorder *MockSyndicationMockRecorder
+}
+
+// MockSyndicationMockRecorder is the mock recorder for MockSyndication.
+type MockSyndicationMockRecorder struct {
+	mock *MockSyndication
+}
+
+// NewMockSyndication creates a new mock instance.
+func NewMockSyndication(ctrl *gomock.Controller) *MockSyndication {
+	mock := &MockSyndication{ctrl: ctrl}
+	mock.recorder = &MockSyndicationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSyndication) EXPECT() *MockSyndicationMockRecorder {
+	return m.recorder
+}
+
+// AuthorFeed mocks base method.
+func (m *MockSyndication) AuthorFeed(ctx context.Context, authorId int64, self string) (feed.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorFeed", ctx, authorId, self)
+	ret0, _ := ret[0].(feed.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthorFeed indicates an expected call of AuthorFeed.
+func (mr *MockSyndicationMockRecorder) AuthorFeed(ctx, authorId, self interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorFeed", reflect.TypeOf((*MockSyndication)(nil).AuthorFeed), ctx, authorId, self)
+}
+
+// BlogFeed mocks base method.
+func (m *MockSyndication) BlogFeed(ctx context.Context, self string) (feed.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlogFeed", ctx, self)
+	ret0, _ := ret[0].(feed.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlogFeed indicates an expected call of BlogFeed.
+func (mr *MockSyndicationMockRecorder) BlogFeed(ctx, self interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlogFeed", reflect.TypeOf((*MockSyndication)(nil).BlogFeed), ctx, self)
+}
+
+// MockSitemap is a mock of Sitemap interface.
+type MockSitemap struct {
+	ctrl     *gomock.Controller
+	recorder *MockSitemapMockRecorder
+}
+
+// MockSitemapMockRecorder is the mock recorder for MockSitemap.
+type MockSitemapMockRecorder struct {
+	mock *MockSitemap
+}
+
+// NewMockSitemap creates a new mock instance.
+func NewMockSitemap(ctrl *gomock.Controller) *MockSitemap {
+	mock := &MockSitemap{ctrl: ctrl}
+	mock.recorder = &MockSitemapMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSitemap) EXPECT() *MockSitemapMockRecorder {
+	return m.recorder
+}
+
+// Write mocks base method.
+func (m *MockSitemap) Write(ctx context.Context, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Write", ctx, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Write indicates an expected call of Write.
+func (mr *MockSitemapMockRecorder) Write(ctx, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockSitemap)(nil).Write), ctx, w)
+}
+
+// WritePage mocks base method.
+func (m *MockSitemap) WritePage(ctx context.Context, w io.Writer, page int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WritePage", ctx, w, page)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WritePage indicates an expected call of WritePage.
+func (mr *MockSitemapMockRecorder) WritePage(ctx, w, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WritePage", reflect.TypeOf((*MockSitemap)(nil).WritePage), ctx, w, page)
+}
+
+// MockMedia is a mock of Media interface.
+type MockMedia struct {
+	ctrl     *gomock.Controller
+	recorder *MockMediaMockRecorder
+}
+
+// MockMediaMockRecorder is the mock recorder for MockMedia.
+type MockMediaMockRecorder struct {
+	mock *MockMedia
+}
+
+// NewMockMedia creates a new mock instance.
+func NewMockMedia(ctrl *gomock.Controller) *MockMedia {
+	mock := &MockMedia{ctrl: ctrl}
+	mock.recorder = &MockMediaMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMedia) EXPECT() *MockMediaMockRecorder {
+	return m.recorder
+}
+
+// Attach mocks base method.
+func (m *MockMedia) Attach(ctx context.Context, postId, mediaId, userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Attach", ctx, postId, mediaId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Attach indicates an expected call of Attach.
+func (mr *MockMediaMockRecorder) Attach(ctx, postId, mediaId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Attach", reflect.TypeOf((*MockMedia)(nil).Attach), ctx, postId, mediaId, userId)
+}
+
+// Detach mocks base method.
+func (m *MockMedia) Detach(ctx context.Context, postId, mediaId, userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Detach", ctx, postId, mediaId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Detach indicates an expected call of Detach.
+func (mr *MockMediaMockRecorder) Detach(ctx, postId, mediaId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Detach", reflect.TypeOf((*MockMedia)(nil).Detach), ctx, postId, mediaId, userId)
+}
+
+// Open mocks base method.
+func (m *MockMedia) Open(ctx context.Context, id int64) (domain.Media, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Open", ctx, id)
+	ret0, _ := ret[0].(domain.Media)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockMediaMockRecorder) Open(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockMedia)(nil).Open), ctx, id)
+}
+
+// OpenVariant mocks base method.
+func (m *MockMedia) OpenVariant(ctx context.Context, id int64, name, mimeType string) (domain.MediaVariant, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenVariant", ctx, id, name, mimeType)
+	ret0, _ := ret[0].(domain.MediaVariant)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// OpenVariant indicates an expected call of OpenVariant.
+func (mr *MockMediaMockRecorder) OpenVariant(ctx, id, name, mimeType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenVariant", reflect.TypeOf((*MockMedia)(nil).OpenVariant), ctx, id, name, mimeType)
+}
+
+// Upload mocks base method.
+func (m *MockMedia) Upload(ctx context.Context, userId int64, r io.Reader) (domain.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", ctx, userId, r)
+	ret0, _ := ret[0].(domain.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockMediaMockRecorder) Upload(ctx, userId, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockMedia)(nil).Upload), ctx, userId, r)
+}
+
+// MockCache is a mock of Cache interface.
+type MockCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheMockRecorder
+}
+
+// MockCacheMockRecorder is the mock recorder for MockCache.
+type MockCacheMockRecorder struct {
+	mock *MockCache
+}
+
+// NewMockCache creates a new mock instance.
+func NewMockCache(ctrl *gomock.Controller) *MockCache {
+	mock := &MockCache{ctrl: ctrl}
+	mock.recorder = &MockCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCache) EXPECT() *MockCacheMockRecorder {
+	return m.recorder
+}
+
+// FlushKey mocks base method.
+func (m *MockCache) FlushKey(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushKey indicates an expected call of FlushKey.
+func (mr *MockCacheMockRecorder) FlushKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushKey", reflect.TypeOf((*MockCache)(nil).FlushKey), ctx, key)
+}
+
+// FlushPrefix mocks base method.
+func (m *MockCache) FlushPrefix(ctx context.Context, prefix string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushPrefix", ctx, prefix)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FlushPrefix indicates an expected call of FlushPrefix.
+func (mr *MockCacheMockRecorder) FlushPrefix(ctx, prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushPrefix", reflect.TypeOf((*MockCache)(nil).FlushPrefix), ctx, prefix)
+}
+
+// Lookup mocks base method.
+func (m *MockCache) Lookup(ctx context.Context, key string) (json.RawMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lookup", ctx, key)
+	ret0, _ := ret[0].(json.RawMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lookup indicates an expected call of Lookup.
+func (mr *MockCacheMockRecorder) Lookup(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lookup", reflect.TypeOf((*MockCache)(nil).Lookup), ctx, key)
+}
+
+// Stats mocks base method.
+func (m *MockCache) Stats(ctx context.Context) (domain.CacheStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats", ctx)
+	ret0, _ := ret[0].(domain.CacheStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockCacheMockRecorder) Stats(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockCache)(nil).Stats), ctx)
+}
+
 // MockUsers is a mock of Users interface.
 type MockUsers struct {
 	ctrl     *gomock.Controller
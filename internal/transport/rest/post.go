@@ -1,10 +1,15 @@
 package rest
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/Arkosh744/simpleREST_blog/internal/domain"
 	"github.com/gin-gonic/gin"
+	"io"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -19,6 +24,7 @@ import (
 // @Security ApiKeyAuth
 // @param Authorization header string true "Authorization"
 // @Success 201 {object} domain.Post
+// @Failure 422 {object} map[string]interface{}
 // @Router /post/ [post]
 func (h *Handler) Create(c *gin.Context) {
 	var post domain.Post
@@ -30,6 +36,11 @@ func (h *Handler) Create(c *gin.Context) {
 		})
 		return
 	}
+	if err := h.postLimits.Check(post.Title, post.Body); err != nil {
+		log.WithFields(log.Fields{"handler": "NewPost"}).Error(err)
+		invalidPostFields(c, err)
+		return
+	}
 	cookie, err := c.Cookie("refresh-token")
 	if err != nil {
 		log.WithFields(log.Fields{"handler": "NewPost"}).Error(err)
@@ -47,17 +58,17 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	if err := h.postsService.Create(c, post); err != nil {
+	newPost, err := h.postsService.Create(c, post)
+	if err != nil {
 		log.WithFields(log.Fields{"handler": "NewPost"}).Error(err)
 		c.JSON(http.StatusInternalServerError, map[string]string{
 			"message": err.Error(),
 		})
 		return
 	}
-	c.JSON(http.StatusCreated, map[string]interface{}{
-		"title": post.Title,
-		"body":  post.Body,
-	})
+	c.Header("Location", "/post/"+strconv.FormatInt(newPost.Id, 10))
+	c.Header("ETag", postETag(newPost.Version))
+	c.JSON(http.StatusCreated, newPost)
 }
 
 // List posts godoc
@@ -107,11 +118,20 @@ func (h *Handler) List(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Post ID"
+// @Param format query string false "Body only representation" Enums(html, markdown)
 // @Security ApiKeyAuth
 // @param Authorization header string true "Authorization"
 // @Success 200 {object} domain.Post
-// @Router /post/get/{id} [get]
+// @Router /post/{id} [get]
 func (h *Handler) GetById(c *gin.Context) {
+	format := c.Query("format")
+	if !validPostFormat(format) {
+		log.WithFields(log.Fields{"handler": "GetPostById"}).Error("invalid format ", format)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid format",
+		})
+		return
+	}
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		log.WithFields(log.Fields{
@@ -143,39 +163,40 @@ func (h *Handler) GetById(c *gin.Context) {
 	posts, err := h.postsService.GetById(c, id, userId)
 	if err != nil {
 		log.WithFields(log.Fields{"handler": "GetPostById"}).Error(err)
-		c.JSON(http.StatusInternalServerError, map[string]string{
+		c.JSON(postError(err), map[string]string{
 			"message": err.Error(),
 		})
 		return
 	}
-	c.JSON(http.StatusOK, posts)
+	writePost(c, format, posts)
 }
 
-// UpdateById post godoc
-// @Summary Update post by ID
-// @Description Update post by ID
+// GetBySlug post godoc
+// @Summary Get details of a post by slug
+// @Description Get details of a post by its slug, previous slugs are redirected to the current one
 // @Tags posts
 // @Accept  json
 // @Produce  json
-// @Param updatePost body domain.UpdatePost true "update post"
+// @Param slug path string true "Post slug"
+// @Param format query string false "Body only representation" Enums(html, markdown)
 // @Security ApiKeyAuth
 // @param Authorization header string true "Authorization"
 // @Success 200 {object} domain.Post
-// @Router /post/ [put]
-func (h *Handler) UpdateById(c *gin.Context) {
-	var post domain.UpdatePost
-	err := c.BindJSON(&post)
-	if (post.Body == "" && post.Title == "") || err != nil {
-		log.WithFields(log.Fields{"handler": "UpdatePostById"}).Error(err)
+// @Success 301 {string} string "Location of the current slug"
+// @Router /post/by-slug/{slug} [get]
+func (h *Handler) GetBySlug(c *gin.Context) {
+	format := c.Query("format")
+	if !validPostFormat(format) {
+		log.WithFields(log.Fields{"handler": "GetPostBySlug"}).Error("invalid format ", format)
 		c.JSON(http.StatusBadRequest, map[string]string{
-			"message": "invalid input post body",
+			"message": "invalid format",
 		})
 		return
 	}
-
+	slug := c.Param("slug")
 	cookie, err := c.Cookie("refresh-token")
 	if err != nil {
-		log.WithFields(log.Fields{"handler": "UpdatePostById"}).Error(err)
+		log.WithFields(log.Fields{"handler": "GetPostBySlug"}).Error(err)
 		c.JSON(http.StatusUnauthorized, map[string]string{
 			"message": err.Error(),
 		})
@@ -183,20 +204,236 @@ func (h *Handler) UpdateById(c *gin.Context) {
 	}
 	userId, err := h.usersService.GetIdByToken(c, cookie)
 	if err != nil {
-		log.WithFields(log.Fields{"handler": "NewPost"}).Error(err)
+		log.WithFields(log.Fields{"handler": "GetPostBySlug"}).Error(err)
 		c.JSON(http.StatusUnauthorized, map[string]string{
 			"message": err.Error(),
 		})
 		return
 	}
 
-	if err := h.postsService.Update(c, post.Id, post, userId); err != nil {
-		log.WithFields(log.Fields{"handler": "UpdatePostById"}).Error(err)
-		c.JSON(http.StatusInternalServerError, map[string]string{
+	post, err := h.postsService.GetBySlug(c, slug, userId)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "GetPostBySlug"}).Error(err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrPostNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, map[string]string{
 			"message": err.Error(),
 		})
 		return
 	}
+	if post.Slug != slug {
+		location := post.Permalink()
+		if format != "" {
+			location += "?format=" + format
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
+		return
+	}
+	writePost(c, format, post)
+}
+
+// GetPublicPost post godoc
+// @Summary Get a post without an account
+// @Description Get a post by its slug without the reactions and bookmarks of a user, this is where the feeds
+// @Description and the sitemap link to. Previous slugs are redirected to the current one
+// @Tags posts
+// @Produce  json
+// @Param slug path string true "Post slug"
+// @Param format query string false "Body only representation" Enums(html, markdown)
+// @Success 200 {object} domain.Post
+// @Success 301 {string} string "Location of the current slug"
+// @Router /posts/{slug} [get]
+func (h *Handler) GetPublicPost(c *gin.Context) {
+	format := c.Query("format")
+	if !validPostFormat(format) {
+		log.WithFields(log.Fields{"handler": "GetPublicPost"}).Error("invalid format ", format)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid format",
+		})
+		return
+	}
+	slug := c.Param("slug")
+
+	post, err := h.postsService.GetBySlug(c, slug, 0)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "GetPublicPost"}).Error(err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrPostNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	if post.Slug != slug {
+		location := post.PublicLink()
+		if format != "" {
+			location += "?format=" + format
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
+		return
+	}
+	writePost(c, format, post)
+}
+
+// ReplacePost post godoc
+// @Summary Replace post by ID
+// @Description Replace the title and body of a post by ID
+// @Tags posts
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param post body domain.PostQuery true "post"
+// @Param If-Match header string true "ETag of the post the update is based on, or *"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} domain.Post
+// @Failure 412 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 428 {object} map[string]interface{}
+// @Router /post/{id} [put]
+func (h *Handler) ReplacePost(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "ReplacePost"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post id",
+		})
+		return
+	}
+	var inp domain.PostQuery
+	err = c.BindJSON(&inp)
+	if inp.Body == "" || inp.Title == "" || err != nil {
+		log.WithFields(log.Fields{"handler": "ReplacePost"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post body",
+		})
+		return
+	}
+
+	post, ok := h.updatePost(c, "ReplacePost", domain.UpdatePost{Id: id, Title: &inp.Title, Body: &inp.Body})
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, post)
+}
+
+// PatchPost post godoc
+// @Summary Patch post by ID
+// @Description Partially update a post by ID with a JSON Merge Patch (RFC 7396), null clears the body
+// @Tags posts
+// @Accept  application/merge-patch+json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param patch body domain.PostQuery true "merge patch of the post"
+// @Param If-Match header string true "ETag of the post the update is based on, or *"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} domain.Post
+// @Failure 412 {object} map[string]interface{}
+// @Failure 415 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 428 {object} map[string]interface{}
+// @Router /post/{id} [patch]
+func (h *Handler) PatchPost(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "PatchPost"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post id",
+		})
+		return
+	}
+	if contentType := c.ContentType(); contentType != mergePatchContentType && contentType != gin.MIMEJSON {
+		log.WithFields(log.Fields{"handler": "PatchPost"}).Error("unsupported content type ", contentType)
+		c.JSON(http.StatusUnsupportedMediaType, map[string]string{
+			"message": "patch must be " + mergePatchContentType,
+		})
+		return
+	}
+	post, err := decodeMergePatch(c.Request.Body)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "PatchPost"}).Error(err)
+		invalidPostFields(c, err)
+		return
+	}
+	if post.Title == nil && post.Body == nil {
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post body",
+		})
+		return
+	}
+	post.Id = id
+
+	newPost, ok := h.updatePost(c, "PatchPost", post)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newPost)
+}
+
+// DeletePost post godoc
+// @Summary Delete a post by ID
+// @Description Move a post of the current user to the trash by ID
+// @Tags posts
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {string} string {"message": "deleted"}
+// @Router /post/{id} [delete]
+func (h *Handler) DeletePost(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "DeletePost"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post id",
+		})
+		return
+	}
+	h.deletePost(c, "DeletePost", id)
+}
+
+// UpdateById post godoc
+// @Summary Update post by ID
+// @Description Update post by ID taken from the body, empty fields are left unchanged. Use PUT or PATCH /post/{id}
+// @Tags posts
+// @Accept  json
+// @Produce  json
+// @Param updatePost body domain.UpdatePost true "update post"
+// @Param If-Match header string true "ETag of the post the update is based on, or *"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {string} string {"message": "updated"}
+// @Failure 412 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 428 {object} map[string]interface{}
+// @Deprecated
+// @Router /post/ [put]
+func (h *Handler) UpdateById(c *gin.Context) {
+	var post domain.UpdatePost
+	err := c.BindJSON(&post)
+	if post.Title != nil && *post.Title == "" {
+		post.Title = nil
+	}
+	if post.Body != nil && *post.Body == "" {
+		post.Body = nil
+	}
+	if (post.Body == nil && post.Title == nil) || err != nil {
+		log.WithFields(log.Fields{"handler": "UpdatePostById"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post body",
+		})
+		return
+	}
+
+	if _, ok := h.updatePost(c, "UpdatePostById", post); !ok {
+		return
+	}
 	c.JSON(http.StatusOK, map[string]interface{}{
 		"message": "updated",
 	})
@@ -204,7 +441,7 @@ func (h *Handler) UpdateById(c *gin.Context) {
 
 // DeleteById post godoc
 // @Summary Delete a post by ID
-// @Description Delete a post by ID
+// @Description Delete a post by ID taken from the body. Use DELETE /post/{id}
 // @Tags posts
 // @Accept  json
 // @Produce  json
@@ -212,21 +449,134 @@ func (h *Handler) UpdateById(c *gin.Context) {
 // @Security ApiKeyAuth
 // @param Authorization header string true "Authorization"
 // @Success 200 {string} string {"message": "deleted"}
+// @Deprecated
 // @Router /post/ [delete]
 func (h *Handler) DeleteById(c *gin.Context) {
 	var post *domain.UpdatePost
 	err := c.BindJSON(&post)
-	if post.Id == 0 || err != nil {
+	if post == nil || post.Id == 0 || err != nil {
 		log.WithFields(log.Fields{"handler": "DeletePostById"}).Error(err)
 		c.JSON(http.StatusBadRequest, map[string]string{
 			"message": "invalid input post body",
 		})
 		return
 	}
+	h.deletePost(c, "DeletePostById", post.Id)
+}
+
+// updatePost checks the update of the current user against the limits and the If-Match version and applies it.
+// The error response is already written when it returns false.
+func (h *Handler) updatePost(c *gin.Context, handler string, post domain.UpdatePost) (domain.Post, bool) {
+	if err := h.checkUpdate(post); err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		invalidPostFields(c, err)
+		return domain.Post{}, false
+	}
+
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return domain.Post{}, false
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return domain.Post{}, false
+	}
+
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, map[string]string{
+			"message": "If-Match header is required",
+		})
+		return domain.Post{}, false
+	}
+	post.Version, err = parseIfMatch(ifMatch)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusPreconditionFailed, map[string]string{
+			"message": err.Error(),
+		})
+		return domain.Post{}, false
+	}
+
+	newPost, err := h.postsService.Update(c, post.Id, post, userId)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(postError(err), map[string]string{
+			"message": err.Error(),
+		})
+		return domain.Post{}, false
+	}
+	c.Header("ETag", postETag(newPost.Version))
+	return newPost, true
+}
+
+func (h *Handler) deletePost(c *gin.Context, handler string, id int64) {
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.postsService.Delete(c, id, userId); err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(postError(err), map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "deleted",
+	})
+}
 
+// checkUpdate validates the changed fields of the post, the title can't be cleared.
+func (h *Handler) checkUpdate(post domain.UpdatePost) error {
+	var title, body string
+	if post.Title != nil {
+		if *post.Title == "" {
+			return &domain.ValidationError{Fields: []domain.FieldError{{Field: "title", Message: "must not be empty"}}}
+		}
+		title = *post.Title
+	}
+	if post.Body != nil {
+		body = *post.Body
+	}
+	return h.postLimits.Check(title, body)
+}
+
+// ListTrash posts godoc
+// @Summary Get List of trashed posts
+// @Description Get List of posts of the current user that were deleted and not purged yet
+// @Tags posts
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {array} []domain.Post
+// @Router /post/trash [get]
+func (h *Handler) ListTrash(c *gin.Context) {
 	cookie, err := c.Cookie("refresh-token")
 	if err != nil {
-		log.WithFields(log.Fields{"handler": "DeletePostById"}).Error(err)
+		log.WithFields(log.Fields{"handler": "ListTrash"}).Error(err)
 		c.JSON(http.StatusUnauthorized, map[string]string{
 			"message": err.Error(),
 		})
@@ -234,21 +584,203 @@ func (h *Handler) DeleteById(c *gin.Context) {
 	}
 	userId, err := h.usersService.GetIdByToken(c, cookie)
 	if err != nil {
-		log.WithFields(log.Fields{"handler": "NewPost"}).Error(err)
+		log.WithFields(log.Fields{"handler": "ListTrash"}).Error(err)
 		c.JSON(http.StatusUnauthorized, map[string]string{
 			"message": err.Error(),
 		})
 		return
 	}
 
-	if err := h.postsService.Delete(c, post.Id, userId); err != nil {
-		log.WithFields(log.Fields{"handler": "DeletePostById"}).Error(err)
+	posts, err := h.postsService.ListTrash(c, userId)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "ListTrash"}).Error(err)
 		c.JSON(http.StatusInternalServerError, map[string]string{
 			"message": err.Error(),
 		})
 		return
 	}
+
+	c.JSON(http.StatusOK, posts)
+}
+
+// Restore post godoc
+// @Summary Restore a post from the trash
+// @Description Restore a deleted post of the current user by ID
+// @Tags posts
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {string} string {"message": "restored"}
+// @Router /post/{id}/restore [post]
+func (h *Handler) Restore(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "RestorePost"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post id",
+		})
+		return
+	}
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "RestorePost"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "RestorePost"}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.postsService.Restore(c, id, userId); err != nil {
+		log.WithFields(log.Fields{"handler": "RestorePost"}).Error(err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrPostNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
 	c.JSON(http.StatusOK, map[string]interface{}{
-		"message": "deleted",
+		"message": "restored",
+	})
+}
+
+const (
+	postFormatHTML     = "html"
+	postFormatMarkdown = "markdown"
+)
+
+func validPostFormat(format string) bool {
+	return format == "" || format == postFormatHTML || format == postFormatMarkdown
+}
+
+// writePost responds with the whole post as JSON or only with its body
+// in the representation asked by the format query parameter.
+func writePost(c *gin.Context, format string, post domain.Post) {
+	if format != "" && !post.UpdatedAt.IsZero() {
+		// only the body changes the post, the JSON also has the counters and is checked by the ETag alone
+		c.Header("Last-Modified", post.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	switch format {
+	case postFormatHTML:
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(post.BodyHTML))
+	case postFormatMarkdown:
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(post.Body))
+	default:
+		if post.Version != 0 {
+			c.Header("ETag", postETag(post.Version))
+		}
+		c.JSON(http.StatusOK, post)
+	}
+}
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// decodeMergePatch reads a JSON Merge Patch (RFC 7396) of a post. Members that are left out stay unchanged
+// and null clears the field, only the title and the body can be patched.
+func decodeMergePatch(r io.Reader) (domain.UpdatePost, error) {
+	var members map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&members); err != nil {
+		return domain.UpdatePost{}, err
+	}
+	if members == nil {
+		return domain.UpdatePost{}, errors.New("merge patch must be an object")
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var post domain.UpdatePost
+	var fields []domain.FieldError
+	for _, name := range names {
+		var field **string
+		switch name {
+		case "title":
+			field = &post.Title
+		case "body":
+			field = &post.Body
+		default:
+			fields = append(fields, domain.FieldError{Field: name, Message: "can't be changed"})
+			continue
+		}
+
+		var value string
+		if string(members[name]) != "null" {
+			if err := json.Unmarshal(members[name], &value); err != nil {
+				fields = append(fields, domain.FieldError{Field: name, Message: "must be a string"})
+				continue
+			}
+		}
+		*field = &value
+	}
+
+	if len(fields) > 0 {
+		return domain.UpdatePost{}, &domain.ValidationError{Fields: fields}
+	}
+	return post, nil
+}
+
+// postETag is the entity tag of a post version, If-Match of the updates is checked against it.
+func postETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseIfMatch returns the post version of the If-Match header, zero for "*".
+// Weak tags never match, since the update needs the exact version it is based on.
+func parseIfMatch(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return 0, nil
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, domain.ErrVersionMismatch
+	}
+	// the tags of the GET responses carry the hash of the body after the version
+	tag := strings.SplitN(header[1:len(header)-1], "-", 2)[0]
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, domain.ErrVersionMismatch
+	}
+	return version, nil
+}
+
+func postError(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrPostNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// invalidPostFields responds with the field level details of the post validation error.
+func invalidPostFields(c *gin.Context, err error) {
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post body",
+		})
+		return
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+		"message": "invalid input post body",
+		"errors":  validationErr.Fields,
 	})
 }
@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestHandler_Create(t *testing.T) {
@@ -26,6 +27,7 @@ func TestHandler_Create(t *testing.T) {
 		mockCookie           *http.Cookie
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
+		expectedLocation     string
 		expectedResponseBody string
 	}{
 		{
@@ -43,10 +45,18 @@ func TestHandler_Create(t *testing.T) {
 			},
 			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inp domain.Post) {
 				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
-				mockPost.EXPECT().Create(gomock.Any(), inp).Return(nil)
+				mockPost.EXPECT().Create(gomock.Any(), inp).Return(domain.Post{
+					Id:       7,
+					Title:    "TestTitle",
+					Body:     "TestBody",
+					Slug:     "testtitle",
+					AuthorId: AuthorId,
+					Version:  1,
+				}, nil)
 			},
 			expectedStatusCode:   201,
-			expectedResponseBody: `{"body":"TestBody","title":"TestTitle"}`,
+			expectedLocation:     "/post/7",
+			expectedResponseBody: `{"id":7,"title":"TestTitle","body":"TestBody","slug":"testtitle","AuthorId":1,"version":1,"createdAt":"0001-01-01T00:00:00Z","updatedAt":"0001-01-01T00:00:00Z"}`,
 		},
 		{
 			name:       "W/o Cookie",
@@ -73,6 +83,19 @@ func TestHandler_Create(t *testing.T) {
 			expectedStatusCode:   400,
 			expectedResponseBody: `{"message":"invalid input post body"}`,
 		},
+		{
+			name:      "Too long",
+			inputBody: `{"title": "TestTitleThatIsTooLong", "body": "TestBody"}`,
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inp domain.Post) {
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"errors":[{"field":"title","message":"must be at most 20 characters, got 22"}],"message":"invalid input post body"}`,
+		},
 		{
 			name:      "Service Error",
 			inputBody: `{"title": "TestTitle", "body": "TestBody"}`,
@@ -88,7 +111,7 @@ func TestHandler_Create(t *testing.T) {
 			},
 			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inp domain.Post) {
 				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
-				mockPost.EXPECT().Create(gomock.Any(), inp).Return(errors.New("something went wrong"))
+				mockPost.EXPECT().Create(gomock.Any(), inp).Return(domain.Post{}, errors.New("something went wrong"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"something went wrong"}`,
@@ -106,7 +129,7 @@ func TestHandler_Create(t *testing.T) {
 			auth := mocks.NewMockUsers(c)
 			test.mockBehavior(post, auth, context.Background(), test.inputPost)
 			fmt.Printf("test.inputPost: %v\n", test.inputPost)
-			handler := NewHandler(post, auth)
+			handler := NewHandler(post, auth).WithPostLimits(domain.PostLimits{TitleMaxLength: 20, BodyMaxLength: 40})
 			// Init Endpoint
 			r := gin.Default()
 			r.POST("/post/", handler.Create)
@@ -121,6 +144,7 @@ func TestHandler_Create(t *testing.T) {
 			r.ServeHTTP(w, req)
 			// Assert
 			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("Location"), test.expectedLocation)
 			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
 		})
 	}
@@ -223,10 +247,12 @@ func TestHandler_GetById(t *testing.T) {
 	tests := []struct {
 		name                 string
 		inputID              int64
+		inputFormat          string
 		mockCookie           *http.Cookie
 		responsePost         domain.Post
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
+		expectedETag         string
 		expectedResponseBody string
 	}{
 		{
@@ -242,13 +268,79 @@ func TestHandler_GetById(t *testing.T) {
 				Title:    "TestTitle",
 				Body:     "TestBody",
 				AuthorId: 1,
+				Version:  2,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inputID int64, responsePost domain.Post) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().GetById(gomock.Any(), inputID, AuthorId).Return(responsePost, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"2"`,
+			expectedResponseBody: `{"id":1,"title":"TestTitle","body":"TestBody","AuthorId":1,"version":2,"createdAt":"0001-01-01T00:00:00Z","updatedAt":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:        "Format html",
+			inputID:     1,
+			inputFormat: "html",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			responsePost: domain.Post{
+				Id:    1,
+				Title: "TestTitle",
+				Body:  "Test **Body**",
+				PostContent: domain.PostContent{
+					BodyHTML: "<p>Test <strong>Body</strong></p>\n",
+				},
+				AuthorId: 1,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inputID int64, responsePost domain.Post) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().GetById(gomock.Any(), inputID, AuthorId).Return(responsePost, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "<p>Test <strong>Body</strong></p>\n",
+		},
+		{
+			name:        "Format markdown",
+			inputID:     1,
+			inputFormat: "markdown",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			responsePost: domain.Post{
+				Id:    1,
+				Title: "TestTitle",
+				Body:  "Test **Body**",
+				PostContent: domain.PostContent{
+					BodyHTML: "<p>Test <strong>Body</strong></p>\n",
+				},
+				AuthorId: 1,
 			},
 			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inputID int64, responsePost domain.Post) {
 				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
 				mockPost.EXPECT().GetById(gomock.Any(), inputID, AuthorId).Return(responsePost, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"id":1,"title":"TestTitle","body":"TestBody","AuthorId":1,"createdAt":"0001-01-01T00:00:00Z","updatedAt":"0001-01-01T00:00:00Z"}`,
+			expectedResponseBody: "Test **Body**",
+		},
+		{
+			name:        "Wrong format",
+			inputID:     1,
+			inputFormat: "pdf",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inputID int64, responsePost domain.Post) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid format"}`,
 		},
 		{
 			name:       "W/o Cookie",
@@ -287,6 +379,21 @@ func TestHandler_GetById(t *testing.T) {
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"something went wrong"}`,
 		},
+		{
+			name:    "Not found",
+			inputID: 1,
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inputID int64, responsePost domain.Post) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().GetById(gomock.Any(), inputID, AuthorId).Return(domain.Post{}, domain.ErrPostNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}`,
+		},
 	}
 	gin.SetMode(gin.TestMode)
 	for _, test := range tests {
@@ -307,6 +414,9 @@ func TestHandler_GetById(t *testing.T) {
 			if test.name == "Wrong input" {
 				reqID = fmt.Sprintf("/post/%v", "wrong")
 			}
+			if test.inputFormat != "" {
+				reqID += "?format=" + test.inputFormat
+			}
 			r.GET("post/:id", handler.GetById)
 
 			// Create Request
@@ -318,6 +428,7 @@ func TestHandler_GetById(t *testing.T) {
 			r.ServeHTTP(w, req)
 			// Assert
 			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), test.expectedETag)
 			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
 		})
 	}
@@ -331,19 +442,66 @@ func TestHandler_UpdateById(t *testing.T) {
 		name                 string
 		inputBody            string
 		inputPost            domain.UpdatePost
+		ifMatch              string
 		mockCookie           *http.Cookie
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
+		expectedETag         string
 		expectedResponseBody string
 	}{
 		{
 			name:      "Ok",
 			inputBody: `{"id":1, "title": "TestTitleNew", "body": "TestBodyNew"}`,
+			inputPost: domain.UpdatePost{
+				Id:      1,
+				Title:   stringPtr("TestTitleNew"),
+				Body:    stringPtr("TestBodyNew"),
+				Version: 3,
+			},
+			ifMatch: `"3"`,
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inp domain.UpdatePost) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Update(gomock.Any(), inp.Id, inp, AuthorId).Return(domain.Post{Id: 1, Version: 4}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"4"`,
+			expectedResponseBody: `{"message":"updated"}`,
+		},
+		{
+			name:      "ETag of GET response",
+			inputBody: `{"id":1, "title": "TestTitleNew"}`,
+			inputPost: domain.UpdatePost{
+				Id:      1,
+				Title:   stringPtr("TestTitleNew"),
+				Version: 3,
+			},
+			ifMatch: `"3-5d41402abc4b2a76b9719d911017c592"`,
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inp domain.UpdatePost) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Update(gomock.Any(), inp.Id, inp, AuthorId).Return(domain.Post{Id: 1, Version: 4}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"4"`,
+			expectedResponseBody: `{"message":"updated"}`,
+		},
+		{
+			name:      "Any version",
+			inputBody: `{"id":1, "title": "TestTitleNew"}`,
 			inputPost: domain.UpdatePost{
 				Id:    1,
-				Title: "TestTitleNew",
-				Body:  "TestBodyNew",
+				Title: stringPtr("TestTitleNew"),
 			},
+			ifMatch: "*",
 			mockCookie: &http.Cookie{
 				Name:   "refresh-token",
 				Value:  "refreshToken",
@@ -351,11 +509,63 @@ func TestHandler_UpdateById(t *testing.T) {
 			},
 			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inp domain.UpdatePost) {
 				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
-				mockPost.EXPECT().Update(gomock.Any(), inp.Id, inp, AuthorId).Return(nil)
+				mockPost.EXPECT().Update(gomock.Any(), inp.Id, inp, AuthorId).Return(domain.Post{Id: 1, Version: 8}, nil)
 			},
 			expectedStatusCode:   200,
+			expectedETag:         `"8"`,
 			expectedResponseBody: `{"message":"updated"}`,
 		},
+		{
+			name:      "W/o If-Match",
+			inputBody: `{"id":1, "title": "TestTitleNew", "body": "TestBodyNew"}`,
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inp domain.UpdatePost) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+			},
+			expectedStatusCode:   428,
+			expectedResponseBody: `{"message":"If-Match header is required"}`,
+		},
+		{
+			name:      "Weak If-Match",
+			inputBody: `{"id":1, "title": "TestTitleNew", "body": "TestBodyNew"}`,
+			ifMatch:   `W/"3"`,
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inp domain.UpdatePost) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"message":"post has been modified since it was read"}`,
+		},
+		{
+			name:      "Version mismatch",
+			inputBody: `{"id":1, "title": "TestTitleNew", "body": "TestBodyNew"}`,
+			inputPost: domain.UpdatePost{
+				Id:      1,
+				Title:   stringPtr("TestTitleNew"),
+				Body:    stringPtr("TestBodyNew"),
+				Version: 2,
+			},
+			ifMatch: `"2"`,
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inp domain.UpdatePost) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Update(gomock.Any(), inp.Id, inp, AuthorId).Return(domain.Post{}, domain.ErrVersionMismatch)
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"message":"post has been modified since it was read"}`,
+		},
 		{
 			name:       "W/o Cookie",
 			inputBody:  `{"id":1, "title": "TestTitleNew", "body": "TestBodyNew"}`,
@@ -379,14 +589,29 @@ func TestHandler_UpdateById(t *testing.T) {
 			expectedStatusCode:   400,
 			expectedResponseBody: `{"message":"invalid input post body"}`,
 		},
+		{
+			name:      "Too long",
+			inputBody: `{"id":1, "body": "TestBodyNewThatIsMuchLongerThanTheLimitAllows"}`,
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inp domain.UpdatePost) {
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"errors":[{"field":"body","message":"must be at most 40 characters, got 45"}],"message":"invalid input post body"}`,
+		},
 		{
 			name:      "Service Error",
 			inputBody: `{"id":1, "title": "TestTitleNew", "body": "TestBodyNew"}`,
 			inputPost: domain.UpdatePost{
-				Id:    1,
-				Title: "TestTitleNew",
-				Body:  "TestBodyNew",
+				Id:      1,
+				Title:   stringPtr("TestTitleNew"),
+				Body:    stringPtr("TestBodyNew"),
+				Version: 3,
 			},
+			ifMatch: `"3"`,
 			mockCookie: &http.Cookie{
 				Name:   "refresh-token",
 				Value:  "refreshToken",
@@ -394,7 +619,7 @@ func TestHandler_UpdateById(t *testing.T) {
 			},
 			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, inp domain.UpdatePost) {
 				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
-				mockPost.EXPECT().Update(gomock.Any(), inp.Id, inp, AuthorId).Return(errors.New("something went wrong"))
+				mockPost.EXPECT().Update(gomock.Any(), inp.Id, inp, AuthorId).Return(domain.Post{}, errors.New("something went wrong"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"something went wrong"}`,
@@ -412,21 +637,26 @@ func TestHandler_UpdateById(t *testing.T) {
 			auth := mocks.NewMockUsers(c)
 			test.mockBehavior(post, auth, context.Background(), test.inputPost)
 			fmt.Printf("test.inputPost: %v\n", test.inputPost)
-			handler := NewHandler(post, auth)
+			handler := NewHandler(post, auth).WithPostLimits(domain.PostLimits{TitleMaxLength: 20, BodyMaxLength: 40})
 			// Init Endpoint
 			r := gin.Default()
-			r.PUT("/post/", handler.UpdateById)
+			r.PUT("/post/", deprecatedMiddleware("PUT /post/{id}"), handler.UpdateById)
 
 			// Create Request
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("PUT", "/post/",
 				bytes.NewBufferString(test.inputBody))
+			if test.ifMatch != "" {
+				req.Header.Set("If-Match", test.ifMatch)
+			}
 			mockCookie := test.mockCookie
 			req.AddCookie(mockCookie)
 			// Make Request
 			r.ServeHTTP(w, req)
 			// Assert
 			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), test.expectedETag)
+			assert.Equal(t, w.Header().Get("Deprecation"), "true")
 			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
 		})
 	}
@@ -536,3 +766,712 @@ func TestHandler_DeleteById(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_ReplacePost(t *testing.T) {
+	type mockBehavior func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost)
+	var AuthorId int64 = 1
+	tests := []struct {
+		name                 string
+		inputID              string
+		inputBody            string
+		inputPost            domain.UpdatePost
+		ifMatch              string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedETag         string
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputID:   "1",
+			inputBody: `{"title": "TestTitleNew", "body": "TestBodyNew"}`,
+			inputPost: domain.UpdatePost{
+				Id:      1,
+				Title:   stringPtr("TestTitleNew"),
+				Body:    stringPtr("TestBodyNew"),
+				Version: 3,
+			},
+			ifMatch: `"3"`,
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Update(gomock.Any(), int64(1), inp, AuthorId).
+					Return(domain.Post{Id: 1, Title: "TestTitleNew", Body: "TestBodyNew", AuthorId: 1, Version: 4}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"4"`,
+			expectedResponseBody: `{"id":1,"title":"TestTitleNew","body":"TestBodyNew","AuthorId":1,"version":4,"createdAt":"0001-01-01T00:00:00Z","updatedAt":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:      "W/o body",
+			inputID:   "1",
+			inputBody: `{"title": "TestTitleNew"}`,
+			ifMatch:   `"3"`,
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input post body"}`,
+		},
+		{
+			name:      "Wrong id",
+			inputID:   "abc",
+			inputBody: `{"title": "TestTitleNew", "body": "TestBodyNew"}`,
+			ifMatch:   `"3"`,
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input post id"}`,
+		},
+		{
+			name:      "Post not found",
+			inputID:   "1",
+			inputBody: `{"title": "TestTitleNew", "body": "TestBodyNew"}`,
+			inputPost: domain.UpdatePost{
+				Id:    1,
+				Title: stringPtr("TestTitleNew"),
+				Body:  stringPtr("TestBodyNew"),
+			},
+			ifMatch: "*",
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Update(gomock.Any(), int64(1), inp, AuthorId).Return(domain.Post{}, domain.ErrPostNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			test.mockBehavior(post, auth, test.inputPost)
+			handler := NewHandler(post, auth).WithPostLimits(domain.PostLimits{TitleMaxLength: 20, BodyMaxLength: 40})
+			// Init Endpoint
+			r := gin.Default()
+			r.PUT("/post/:id", handler.ReplacePost)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/post/"+test.inputID, bytes.NewBufferString(test.inputBody))
+			req.Header.Set("If-Match", test.ifMatch)
+			req.AddCookie(&http.Cookie{Name: "refresh-token", Value: "refreshToken", MaxAge: 2592000})
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), test.expectedETag)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_PatchPost(t *testing.T) {
+	type mockBehavior func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost)
+	var AuthorId int64 = 1
+	tests := []struct {
+		name                 string
+		contentType          string
+		inputBody            string
+		inputPost            domain.UpdatePost
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"title": "TestTitleNew"}`,
+			inputPost: domain.UpdatePost{
+				Id:      1,
+				Title:   stringPtr("TestTitleNew"),
+				Version: 3,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Update(gomock.Any(), int64(1), inp, AuthorId).
+					Return(domain.Post{Id: 1, Title: "TestTitleNew", Body: "TestBody", AuthorId: 1, Version: 4}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1,"title":"TestTitleNew","body":"TestBody","AuthorId":1,"version":4,"createdAt":"0001-01-01T00:00:00Z","updatedAt":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:        "Clear body",
+			contentType: "application/merge-patch+json; charset=utf-8",
+			inputBody:   `{"body": null}`,
+			inputPost: domain.UpdatePost{
+				Id:      1,
+				Body:    stringPtr(""),
+				Version: 3,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Update(gomock.Any(), int64(1), inp, AuthorId).
+					Return(domain.Post{Id: 1, Title: "TestTitle", AuthorId: 1, Version: 4}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1,"title":"TestTitle","body":"","AuthorId":1,"version":4,"createdAt":"0001-01-01T00:00:00Z","updatedAt":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:        "Clear title",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"title": null}`,
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost) {
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"errors":[{"field":"title","message":"must not be empty"}],"message":"invalid input post body"}`,
+		},
+		{
+			name:        "Read-only fields",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"id": 2, "title": 5}`,
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost) {
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"errors":[{"field":"id","message":"can't be changed"},{"field":"title","message":"must be a string"}],"message":"invalid input post body"}`,
+		},
+		{
+			name:        "Not an object",
+			contentType: "application/merge-patch+json",
+			inputBody:   `null`,
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input post body"}`,
+		},
+		{
+			name:        "Empty patch",
+			contentType: "application/json",
+			inputBody:   `{}`,
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input post body"}`,
+		},
+		{
+			name:        "Wrong content type",
+			contentType: "application/json-patch+json",
+			inputBody:   `[{"op": "remove", "path": "/body"}]`,
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost) {
+			},
+			expectedStatusCode:   415,
+			expectedResponseBody: `{"message":"patch must be application/merge-patch+json"}`,
+		},
+		{
+			name:        "Version mismatch",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"body": "TestBodyNew"}`,
+			inputPost: domain.UpdatePost{
+				Id:      1,
+				Body:    stringPtr("TestBodyNew"),
+				Version: 3,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, inp domain.UpdatePost) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Update(gomock.Any(), int64(1), inp, AuthorId).Return(domain.Post{}, domain.ErrVersionMismatch)
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"message":"post has been modified since it was read"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			test.mockBehavior(post, auth, test.inputPost)
+			handler := NewHandler(post, auth).WithPostLimits(domain.PostLimits{TitleMaxLength: 20, BodyMaxLength: 40})
+			// Init Endpoint
+			r := gin.Default()
+			r.PATCH("/post/:id", handler.PatchPost)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/post/1", bytes.NewBufferString(test.inputBody))
+			req.Header.Set("Content-Type", test.contentType)
+			req.Header.Set("If-Match", `"3"`)
+			req.AddCookie(&http.Cookie{Name: "refresh-token", Value: "refreshToken", MaxAge: 2592000})
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_DeletePost(t *testing.T) {
+	type mockBehavior func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers)
+	var AuthorId int64 = 1
+	tests := []struct {
+		name                 string
+		inputID              string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:    "Ok",
+			inputID: "1",
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Delete(gomock.Any(), int64(1), AuthorId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"deleted"}`,
+		},
+		{
+			name:    "Wrong id",
+			inputID: "abc",
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input post id"}`,
+		},
+		{
+			name:    "Post not found",
+			inputID: "1",
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Delete(gomock.Any(), int64(1), AuthorId).Return(domain.ErrPostNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			test.mockBehavior(post, auth)
+			handler := NewHandler(post, auth)
+			// Init Endpoint
+			r := gin.Default()
+			r.DELETE("/post/:id", handler.DeletePost)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/post/"+test.inputID, nil)
+			req.AddCookie(&http.Cookie{Name: "refresh-token", Value: "refreshToken", MaxAge: 2592000})
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_ListTrash(t *testing.T) {
+	type mockBehavior func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers,
+		ctx context.Context, responsePosts []domain.Post)
+	var AuthorId int64 = 1
+	deletedAt := time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC)
+	tests := []struct {
+		name                 string
+		mockCookie           *http.Cookie
+		responsePost         []domain.Post
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Ok",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			responsePost: []domain.Post{{
+				Id:        1,
+				Title:     "TestTitle",
+				Body:      "TestBody",
+				AuthorId:  1,
+				DeletedAt: &deletedAt,
+			}},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, responsePosts []domain.Post) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().ListTrash(gomock.Any(), AuthorId).Return(responsePosts, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[{"id":1,"title":"TestTitle","body":"TestBody","AuthorId":1,"createdAt":"0001-01-01T00:00:00Z","updatedAt":"0001-01-01T00:00:00Z","deletedAt":"2022-10-12T15:21:56Z"}]`,
+		},
+		{
+			name:       "W/o Cookie",
+			mockCookie: &http.Cookie{},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, responsePosts []domain.Post) {
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"http: named cookie not present"}`,
+		},
+		{
+			name: "Service Error",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, responsePosts []domain.Post) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().ListTrash(gomock.Any(), AuthorId).Return([]domain.Post{}, errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			test.mockBehavior(post, auth, context.Background(), test.responsePost)
+			handler := NewHandler(post, auth)
+			// Init Endpoint
+			r := gin.Default()
+			r.GET("/post/trash", handler.ListTrash)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/post/trash", nil)
+			mockCookie := test.mockCookie
+			req.AddCookie(mockCookie)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_Restore(t *testing.T) {
+	type mockBehavior func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers,
+		ctx context.Context, id int64)
+	var AuthorId int64 = 1
+	tests := []struct {
+		name                 string
+		inputID              string
+		mockCookie           *http.Cookie
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:    "Ok",
+			inputID: "1",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, id int64) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Restore(gomock.Any(), id, AuthorId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"restored"}`,
+		},
+		{
+			name:       "W/o Cookie",
+			inputID:    "1",
+			mockCookie: &http.Cookie{},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, id int64) {
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"http: named cookie not present"}`,
+		},
+		{
+			name:    "Wrong input",
+			inputID: "wrong",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, id int64) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input post id"}`,
+		},
+		{
+			name:    "Not in trash",
+			inputID: "1",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, id int64) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Restore(gomock.Any(), id, AuthorId).Return(domain.ErrPostNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}`,
+		},
+		{
+			name:    "Service Error",
+			inputID: "1",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, id int64) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().Restore(gomock.Any(), id, AuthorId).Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			test.mockBehavior(post, auth, context.Background(), 1)
+			handler := NewHandler(post, auth)
+			// Init Endpoint
+			r := gin.Default()
+			r.POST("/post/:id/restore", handler.Restore)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", fmt.Sprintf("/post/%s/restore", test.inputID), nil)
+			mockCookie := test.mockCookie
+			req.AddCookie(mockCookie)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_GetBySlug(t *testing.T) {
+	type mockBehavior func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers,
+		ctx context.Context, slug string, responsePost domain.Post)
+	var AuthorId int64 = 1
+	tests := []struct {
+		name                 string
+		inputSlug            string
+		mockCookie           *http.Cookie
+		responsePost         domain.Post
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedLocation     string
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputSlug: "test-title",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			responsePost: domain.Post{
+				Id:       1,
+				Title:    "Test Title",
+				Body:     "TestBody",
+				Slug:     "test-title",
+				AuthorId: 1,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, slug string, responsePost domain.Post) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().GetBySlug(gomock.Any(), slug, AuthorId).Return(responsePost, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1,"title":"Test Title","body":"TestBody","slug":"test-title","AuthorId":1,"createdAt":"0001-01-01T00:00:00Z","updatedAt":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:      "Old slug",
+			inputSlug: "old-title",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			responsePost: domain.Post{
+				Id:       1,
+				Title:    "New Title",
+				Body:     "TestBody",
+				Slug:     "new-title",
+				AuthorId: 1,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, slug string, responsePost domain.Post) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().GetBySlug(gomock.Any(), slug, AuthorId).Return(responsePost, nil)
+			},
+			expectedStatusCode:   301,
+			expectedLocation:     "/post/by-slug/new-title",
+			expectedResponseBody: "<a href=\"/post/by-slug/new-title\">Moved Permanently</a>.\n\n",
+		},
+		{
+			name:       "W/o Cookie",
+			inputSlug:  "test-title",
+			mockCookie: &http.Cookie{},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, slug string, responsePost domain.Post) {
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"http: named cookie not present"}`,
+		},
+		{
+			name:      "Not found",
+			inputSlug: "missing",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, mockUser *mocks.MockUsers, ctx context.Context, slug string, responsePost domain.Post) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockPost.EXPECT().GetBySlug(gomock.Any(), slug, AuthorId).Return(domain.Post{}, domain.ErrPostNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			test.mockBehavior(post, auth, context.Background(), test.inputSlug, test.responsePost)
+			handler := NewHandler(post, auth)
+			// Init Endpoint
+			r := gin.Default()
+			r.GET("/post/by-slug/:slug", handler.GetBySlug)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/post/by-slug/"+test.inputSlug, nil)
+			mockCookie := test.mockCookie
+			req.AddCookie(mockCookie)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("Location"), test.expectedLocation)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_GetPublicPost(t *testing.T) {
+	type mockBehavior func(mockPost *mocks.MockPosts, slug string, responsePost domain.Post)
+	tests := []struct {
+		name                 string
+		inputSlug            string
+		responsePost         domain.Post
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedLocation     string
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputSlug: "test-title",
+			responsePost: domain.Post{
+				Id:       1,
+				Title:    "Test Title",
+				Body:     "TestBody",
+				Slug:     "test-title",
+				AuthorId: 1,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, slug string, responsePost domain.Post) {
+				mockPost.EXPECT().GetBySlug(gomock.Any(), slug, int64(0)).Return(responsePost, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1,"title":"Test Title","body":"TestBody","slug":"test-title","AuthorId":1,"createdAt":"0001-01-01T00:00:00Z","updatedAt":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:      "Old slug",
+			inputSlug: "old-title",
+			responsePost: domain.Post{
+				Id:       1,
+				Title:    "New Title",
+				Body:     "TestBody",
+				Slug:     "new-title",
+				AuthorId: 1,
+			},
+			mockBehavior: func(mockPost *mocks.MockPosts, slug string, responsePost domain.Post) {
+				mockPost.EXPECT().GetBySlug(gomock.Any(), slug, int64(0)).Return(responsePost, nil)
+			},
+			expectedStatusCode:   301,
+			expectedLocation:     "/posts/new-title",
+			expectedResponseBody: "<a href=\"/posts/new-title\">Moved Permanently</a>.\n\n",
+		},
+		{
+			name:      "Not found",
+			inputSlug: "missing",
+			mockBehavior: func(mockPost *mocks.MockPosts, slug string, responsePost domain.Post) {
+				mockPost.EXPECT().GetBySlug(gomock.Any(), slug, int64(0)).Return(domain.Post{}, domain.ErrPostNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			test.mockBehavior(post, test.inputSlug, test.responsePost)
+			handler := NewHandler(post, auth)
+			// Init Endpoint
+			r := gin.Default()
+			r.GET("/posts/:slug", handler.GetPublicPost)
+
+			// Create Request, without the cookie
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/posts/"+test.inputSlug, nil)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("Location"), test.expectedLocation)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,100 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// React godoc
+// @Summary React to a post
+// @Description Leave a reaction on a post, reacting twice with the same kind changes nothing
+// @Tags reactions
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param kind path string true "Reaction kind" Enums(like, love, laugh, wow, sad)
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} map[string]interface{}
+// @Router /post/{id}/reactions/{kind} [put]
+func (h *Handler) React(c *gin.Context) {
+	h.changeReaction(c, "React", h.reactionsService.React)
+}
+
+// Unreact godoc
+// @Summary Take a reaction back
+// @Description Remove a reaction from a post, removing a missing one changes nothing
+// @Tags reactions
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param kind path string true "Reaction kind" Enums(like, love, laugh, wow, sad)
+// @Security ApiKeyAuth
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} map[string]interface{}
+// @Router /post/{id}/reactions/{kind} [delete]
+func (h *Handler) Unreact(c *gin.Context) {
+	h.changeReaction(c, "Unreact", h.reactionsService.Unreact)
+}
+
+// changeReaction applies the reaction change of the calling user and responds with the new counters.
+func (h *Handler) changeReaction(c *gin.Context, handler string,
+	change func(ctx context.Context, postId int64, kind string, userId int64) (map[string]int, error)) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input post id",
+		})
+		return
+	}
+	kind := c.Param("kind")
+	if !domain.ValidReaction(kind) {
+		log.WithFields(log.Fields{"handler": handler}).Error("invalid reaction kind ", kind)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": domain.ErrInvalidReaction.Error(),
+		})
+		return
+	}
+	cookie, err := c.Cookie("refresh-token")
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	userId, err := h.usersService.GetIdByToken(c, cookie)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		c.JSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	counts, err := change(c, id, kind, userId)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": handler}).Error(err)
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, domain.ErrInvalidReaction):
+			status = http.StatusBadRequest
+		case errors.Is(err, domain.ErrPostNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"reactions": counts,
+	})
+}
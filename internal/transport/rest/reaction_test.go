@@ -0,0 +1,147 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/transport/rest/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_React(t *testing.T) {
+	type mockBehavior func(mockReaction *mocks.MockReactions, mockUser *mocks.MockUsers)
+	var AuthorId int64 = 1
+
+	tests := []struct {
+		name                 string
+		method               string
+		inputKind            string
+		mockCookie           *http.Cookie
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			method:    "PUT",
+			inputKind: "like",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockReaction *mocks.MockReactions, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockReaction.EXPECT().React(gomock.Any(), int64(1), "like", AuthorId).Return(map[string]int{"like": 3, "wow": 1}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"reactions":{"like":3,"wow":1}}`,
+		},
+		{
+			name:      "Unreact",
+			method:    "DELETE",
+			inputKind: "like",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockReaction *mocks.MockReactions, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockReaction.EXPECT().Unreact(gomock.Any(), int64(1), "like", AuthorId).Return(map[string]int{"like": 2, "wow": 1}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"reactions":{"like":2,"wow":1}}`,
+		},
+		{
+			name:       "W/o Cookie",
+			method:     "PUT",
+			inputKind:  "like",
+			mockCookie: &http.Cookie{},
+			mockBehavior: func(mockReaction *mocks.MockReactions, mockUser *mocks.MockUsers) {
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"http: named cookie not present"}`,
+		},
+		{
+			name:      "Wrong kind",
+			method:    "PUT",
+			inputKind: "angry",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockReaction *mocks.MockReactions, mockUser *mocks.MockUsers) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid reaction kind"}`,
+		},
+		{
+			name:      "Post not found",
+			method:    "PUT",
+			inputKind: "love",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockReaction *mocks.MockReactions, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockReaction.EXPECT().React(gomock.Any(), int64(1), "love", AuthorId).Return(nil, domain.ErrPostNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}`,
+		},
+		{
+			name:      "Service Error",
+			method:    "DELETE",
+			inputKind: "love",
+			mockCookie: &http.Cookie{
+				Name:   "refresh-token",
+				Value:  "refreshToken",
+				MaxAge: 2592000,
+			},
+			mockBehavior: func(mockReaction *mocks.MockReactions, mockUser *mocks.MockUsers) {
+				mockUser.EXPECT().GetIdByToken(gomock.Any(), "refreshToken").Return(AuthorId, nil)
+				mockReaction.EXPECT().Unreact(gomock.Any(), int64(1), "love", AuthorId).Return(nil, errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			reaction := mocks.NewMockReactions(c)
+			test.mockBehavior(reaction, auth)
+			handler := NewHandler(post, auth).WithReactions(reaction)
+			// Init Endpoint
+			r := gin.Default()
+			r.PUT("/post/:id/reactions/:kind", handler.React)
+			r.DELETE("/post/:id/reactions/:kind", handler.Unreact)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, fmt.Sprintf("/post/1/reactions/%s", test.inputKind), nil)
+			req.AddCookie(test.mockCookie)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
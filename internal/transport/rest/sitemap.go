@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"errors"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const sitemapContentType = "application/xml; charset=utf-8"
+
+// SitemapIndex godoc
+// @Summary Sitemap of the posts
+// @Description Get the sitemap with the permalinks of the posts, or the sitemap index when there are more than 50000 posts
+// @Tags sitemap
+// @Produce  xml
+// @Success 200 {string} string "Sitemap or sitemap index document"
+// @Router /sitemap.xml [get]
+func (h *Handler) SitemapIndex(c *gin.Context) {
+	sitemapHeaders(c)
+	err := h.sitemap.Write(c, c.Writer)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "SitemapIndex"}).Error(err)
+		sitemapError(c, err)
+	}
+}
+
+// SitemapPage godoc
+// @Summary Page of the sitemap
+// @Description Get a page of the sitemap listed in the sitemap index
+// @Tags sitemap
+// @Produce  xml
+// @Param page path string true "Page number with the xml extension, like 1.xml"
+// @Success 200 {string} string "Sitemap document"
+// @Router /sitemap/{page} [get]
+func (h *Handler) SitemapPage(c *gin.Context) {
+	name := c.Param("page")
+	page, err := strconv.Atoi(strings.TrimSuffix(name, ".xml"))
+	if err != nil || !strings.HasSuffix(name, ".xml") {
+		log.WithFields(log.Fields{"handler": "SitemapPage"}).Error("invalid sitemap page ", name)
+		sitemapError(c, domain.ErrSitemapNotFound)
+		return
+	}
+
+	sitemapHeaders(c)
+	if err := h.sitemap.WritePage(c, c.Writer, page); err != nil {
+		log.WithFields(log.Fields{"handler": "SitemapPage"}).Error(err)
+		sitemapError(c, err)
+	}
+}
+
+// sitemapHeaders are set before the sitemap is streamed. There is no validator, the sitemap is not buffered to hash it,
+// so the caches only keep it for a while.
+func sitemapHeaders(c *gin.Context) {
+	c.Header("Content-Type", sitemapContentType)
+	c.Header("Cache-Control", cachePublic)
+}
+
+// sitemapError responds with the error unless a part of the streamed sitemap was already sent,
+// then the response can only be cut short.
+func sitemapError(c *gin.Context, err error) {
+	if c.Writer.Written() {
+		c.Abort()
+		return
+	}
+
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Cache-Control")
+	status := http.StatusInternalServerError
+	if errors.Is(err, domain.ErrSitemapNotFound) {
+		status = http.StatusNotFound
+	}
+	c.JSON(status, map[string]string{
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,116 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/transport/rest/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_Sitemap(t *testing.T) {
+	type mockBehavior func(mockSitemap *mocks.MockSitemap)
+
+	tests := []struct {
+		name                 string
+		path                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedContentType  string
+		expectedCacheControl string
+		expectedResponseBody string
+	}{
+		{
+			name: "Sitemap",
+			path: "/sitemap.xml",
+			mockBehavior: func(mockSitemap *mocks.MockSitemap) {
+				mockSitemap.EXPECT().Write(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, w io.Writer) error {
+					_, err := io.WriteString(w, "<urlset></urlset>")
+					return err
+				})
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "application/xml; charset=utf-8",
+			expectedCacheControl: "public, max-age=300",
+			expectedResponseBody: "<urlset></urlset>",
+		},
+		{
+			name: "Page",
+			path: "/sitemap/2.xml",
+			mockBehavior: func(mockSitemap *mocks.MockSitemap) {
+				mockSitemap.EXPECT().WritePage(gomock.Any(), gomock.Any(), 2).DoAndReturn(func(ctx context.Context, w io.Writer, page int) error {
+					_, err := io.WriteString(w, "<urlset></urlset>")
+					return err
+				})
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "application/xml; charset=utf-8",
+			expectedCacheControl: "public, max-age=300",
+			expectedResponseBody: "<urlset></urlset>",
+		},
+		{
+			name:                 "Wrong page",
+			path:                 "/sitemap/two.xml",
+			mockBehavior:         func(mockSitemap *mocks.MockSitemap) {},
+			expectedStatusCode:   404,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedResponseBody: `{"message":"sitemap not found"}`,
+		},
+		{
+			name: "Missing page",
+			path: "/sitemap/3.xml",
+			mockBehavior: func(mockSitemap *mocks.MockSitemap) {
+				mockSitemap.EXPECT().WritePage(gomock.Any(), gomock.Any(), 3).Return(domain.ErrSitemapNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedResponseBody: `{"message":"sitemap not found"}`,
+		},
+		{
+			name: "Service Error",
+			path: "/sitemap.xml",
+			mockBehavior: func(mockSitemap *mocks.MockSitemap) {
+				mockSitemap.EXPECT().Write(gomock.Any(), gomock.Any()).Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedResponseBody: `{"message":"something went wrong"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			sitemap := mocks.NewMockSitemap(c)
+			test.mockBehavior(sitemap)
+			handler := NewHandler(post, auth).WithSitemap(sitemap)
+			// Init Endpoint
+			r := gin.Default()
+			r.GET("/sitemap.xml", handler.SitemapIndex)
+			r.GET("/sitemap/:page", handler.SitemapPage)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", test.path, nil)
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("Content-Type"), test.expectedContentType)
+			assert.Equal(t, w.Header().Get("Cache-Control"), test.expectedCacheControl)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/pkg/feed"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// feedMaxAge lets the feed readers and proxies keep a feed for a while before polling again.
+const feedMaxAge = "public, max-age=300"
+
+// BlogRSS godoc
+// @Summary RSS feed of the blog
+// @Description Get the newest posts of the blog as an RSS 2.0 feed
+// @Tags feeds
+// @Produce  xml
+// @Success 200 {string} string "RSS document"
+// @Success 304 {string} string "Not modified since the previous poll"
+// @Router /feed.rss [get]
+func (h *Handler) BlogRSS(c *gin.Context) {
+	f, err := h.syndication.BlogFeed(c, c.Request.URL.Path)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "BlogRSS"}).Error(err)
+		c.JSON(http.StatusInternalServerError, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	writeFeed(c, "application/rss+xml; charset=utf-8", feed.RSS, f)
+}
+
+// BlogAtom godoc
+// @Summary Atom feed of the blog
+// @Description Get the newest posts of the blog as an Atom feed
+// @Tags feeds
+// @Produce  xml
+// @Success 200 {string} string "Atom document"
+// @Success 304 {string} string "Not modified since the previous poll"
+// @Router /feed.atom [get]
+func (h *Handler) BlogAtom(c *gin.Context) {
+	f, err := h.syndication.BlogFeed(c, c.Request.URL.Path)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "BlogAtom"}).Error(err)
+		c.JSON(http.StatusInternalServerError, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	writeFeed(c, "application/atom+xml; charset=utf-8", feed.Atom, f)
+}
+
+// AuthorAtom godoc
+// @Summary Atom feed of an author
+// @Description Get the newest posts of a user as an Atom feed
+// @Tags feeds
+// @Produce  xml
+// @Param id path int true "User ID"
+// @Success 200 {string} string "Atom document"
+// @Success 304 {string} string "Not modified since the previous poll"
+// @Router /users/{id}/feed.atom [get]
+func (h *Handler) AuthorAtom(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "AuthorAtom"}).Error(err)
+		c.JSON(http.StatusBadRequest, map[string]string{
+			"message": "invalid input user id",
+		})
+		return
+	}
+
+	f, err := h.syndication.AuthorFeed(c, id, c.Request.URL.Path)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "AuthorAtom"}).Error(err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrUserNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+	writeFeed(c, "application/atom+xml; charset=utf-8", feed.Atom, f)
+}
+
+// writeFeed renders the feed and answers 304 when the reader already has the same document.
+func writeFeed(c *gin.Context, contentType string, render func(feed.Feed) ([]byte, error), f feed.Feed) {
+	body, err := render(f)
+	if err != nil {
+		log.WithFields(log.Fields{"handler": "writeFeed"}).Error(err)
+		c.JSON(http.StatusInternalServerError, map[string]string{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	etag := etagOf(body)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", feedMaxAge)
+	lastModified := f.Updated.UTC().Truncate(time.Second)
+	if !f.Updated.IsZero() {
+		c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// etagOf is a strong validator of the response body.
+func etagOf(body []byte) string {
+	sum := sha1.Sum(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// notModified checks the conditional headers of the request, If-None-Match wins over If-Modified-Since.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !lastModified.IsZero() {
+		return !lastModified.After(since)
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"fmt"
+	"github.com/Arkosh744/simpleREST_blog/internal/domain"
+	"github.com/Arkosh744/simpleREST_blog/internal/transport/rest/mocks"
+	"github.com/Arkosh744/simpleREST_blog/pkg/feed"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/magiconair/properties/assert"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_Feeds(t *testing.T) {
+	type mockBehavior func(mockSyndication *mocks.MockSyndication)
+	updated := time.Date(2022, 10, 13, 9, 0, 0, 0, time.UTC)
+	blogFeed := feed.Feed{
+		Title:   "Blog",
+		Link:    "http://localhost:8080/",
+		Self:    "http://localhost:8080/feed.atom",
+		Updated: updated,
+		Items: []feed.Item{{
+			Id:        "tag:localhost,2022-10-12:post-1",
+			Title:     "TestTitle",
+			Link:      "http://localhost:8080/posts/testtitle",
+			Author:    "NewGopher",
+			Content:   "<p>TestBody</p>",
+			Published: updated,
+			Updated:   updated,
+		}},
+	}
+	atom, _ := feed.Atom(blogFeed)
+	etag := etagOf(atom)
+
+	tests := []struct {
+		name                string
+		path                string
+		headers             map[string]string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedContentType string
+		expectedBodyPrefix  string
+	}{
+		{
+			name: "Atom",
+			path: "/feed.atom",
+			mockBehavior: func(mockSyndication *mocks.MockSyndication) {
+				mockSyndication.EXPECT().BlogFeed(gomock.Any(), "/feed.atom").Return(blogFeed, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "application/atom+xml; charset=utf-8",
+			expectedBodyPrefix:  `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<feed xmlns="http://www.w3.org/2005/Atom">`,
+		},
+		{
+			name: "RSS",
+			path: "/feed.rss",
+			mockBehavior: func(mockSyndication *mocks.MockSyndication) {
+				mockSyndication.EXPECT().BlogFeed(gomock.Any(), "/feed.rss").Return(blogFeed, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "application/rss+xml; charset=utf-8",
+			expectedBodyPrefix:  `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<rss version="2.0"`,
+		},
+		{
+			name:    "Same ETag",
+			path:    "/feed.atom",
+			headers: map[string]string{"If-None-Match": etag},
+			mockBehavior: func(mockSyndication *mocks.MockSyndication) {
+				mockSyndication.EXPECT().BlogFeed(gomock.Any(), "/feed.atom").Return(blogFeed, nil)
+			},
+			expectedStatusCode: 304,
+		},
+		{
+			name:    "Changed ETag",
+			path:    "/feed.atom",
+			headers: map[string]string{"If-None-Match": `"old"`, "If-Modified-Since": "Thu, 13 Oct 2022 09:00:00 GMT"},
+			mockBehavior: func(mockSyndication *mocks.MockSyndication) {
+				mockSyndication.EXPECT().BlogFeed(gomock.Any(), "/feed.atom").Return(blogFeed, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "application/atom+xml; charset=utf-8",
+			expectedBodyPrefix:  `<?xml version="1.0" encoding="UTF-8"?>`,
+		},
+		{
+			name:    "Not modified since",
+			path:    "/feed.atom",
+			headers: map[string]string{"If-Modified-Since": "Thu, 13 Oct 2022 09:00:00 GMT"},
+			mockBehavior: func(mockSyndication *mocks.MockSyndication) {
+				mockSyndication.EXPECT().BlogFeed(gomock.Any(), "/feed.atom").Return(blogFeed, nil)
+			},
+			expectedStatusCode: 304,
+		},
+		{
+			name:    "Modified since",
+			path:    "/feed.atom",
+			headers: map[string]string{"If-Modified-Since": "Thu, 13 Oct 2022 08:59:59 GMT"},
+			mockBehavior: func(mockSyndication *mocks.MockSyndication) {
+				mockSyndication.EXPECT().BlogFeed(gomock.Any(), "/feed.atom").Return(blogFeed, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "application/atom+xml; charset=utf-8",
+			expectedBodyPrefix:  `<?xml version="1.0" encoding="UTF-8"?>`,
+		},
+		{
+			name: "Author",
+			path: "/users/2/feed.atom",
+			mockBehavior: func(mockSyndication *mocks.MockSyndication) {
+				mockSyndication.EXPECT().AuthorFeed(gomock.Any(), int64(2), "/users/2/feed.atom").Return(blogFeed, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "application/atom+xml; charset=utf-8",
+			expectedBodyPrefix:  `<?xml version="1.0" encoding="UTF-8"?>`,
+		},
+		{
+			name: "Author not found",
+			path: "/users/5/feed.atom",
+			mockBehavior: func(mockSyndication *mocks.MockSyndication) {
+				mockSyndication.EXPECT().AuthorFeed(gomock.Any(), int64(5), "/users/5/feed.atom").Return(feed.Feed{}, domain.ErrUserNotFound)
+			},
+			expectedStatusCode:  404,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBodyPrefix:  `{"message":"user not found"}`,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fmt.Printf("---------------- Start %s ----------------\n", test.name)
+			// Init Dependencies
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mocks.NewMockPosts(c)
+			auth := mocks.NewMockUsers(c)
+			syndication := mocks.NewMockSyndication(c)
+			test.mockBehavior(syndication)
+			handler := NewHandler(post, auth).WithSyndication(syndication)
+			// Init Endpoint
+			r := gin.Default()
+			r.GET("/feed.rss", handler.BlogRSS)
+			r.GET("/feed.atom", handler.BlogAtom)
+			r.GET("/users/:id/feed.atom", handler.AuthorAtom)
+
+			// Create Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", test.path, nil)
+			for key, value := range test.headers {
+				req.Header.Set(key, value)
+			}
+			// Make Request
+			r.ServeHTTP(w, req)
+			// Assert
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			if test.expectedStatusCode == 304 {
+				assert.Equal(t, w.Body.Len(), 0)
+				return
+			}
+			assert.Equal(t, w.Header().Get("Content-Type"), test.expectedContentType)
+			assert.Equal(t, strings.HasPrefix(w.Body.String(), test.expectedBodyPrefix), true)
+			if test.expectedStatusCode == 200 {
+				assert.Equal(t, w.Header().Get("Last-Modified"), "Thu, 13 Oct 2022 09:00:00 GMT")
+				assert.Equal(t, w.Header().Get("ETag") != "", true)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Breaker stops the calls to a failing service. After failures calls in a row fail it opens and refuses
+// the calls for the cooldown, then it lets one call through: its success closes the breaker, its failure
+// opens it for another cooldown.
+type Breaker struct {
+	failures int
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu sync.Mutex
+	// failed is the number of the calls failed in a row
+	failed    int
+	openUntil time.Time
+	// probing is set while the call after the cooldown is running
+	probing bool
+}
+
+func New(failures int, cooldown time.Duration) *Breaker {
+	if failures < 1 {
+		failures = 1
+	}
+
+	return &Breaker{
+		failures: failures,
+		cooldown: cooldown,
+		now:      time.Now,
+	}
+}
+
+// Allow returns ErrOpen when the call must be skipped, otherwise the result of the call is passed to Done.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failed < b.failures {
+		return nil
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return ErrOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// Done records the result of the allowed call.
+func (b *Breaker) Done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.failed = 0
+		return
+	}
+	if b.failed++; b.failed >= b.failures {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// Open reports whether the calls are refused now.
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.failed >= b.failures && (b.probing || b.now().Before(b.openUntil))
+}
@@ -0,0 +1,43 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC)
+	b := New(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	call := func(failed bool) error {
+		if err := b.Allow(); err != nil {
+			return err
+		}
+		b.Done(failed)
+		return nil
+	}
+
+	// a success in between resets the failures
+	assert.Equal(t, call(true), nil)
+	assert.Equal(t, call(false), nil)
+	assert.Equal(t, call(true), nil)
+	assert.Equal(t, b.Open(), false)
+	assert.Equal(t, call(true), nil)
+	assert.Equal(t, b.Open(), true)
+	assert.Equal(t, call(false), ErrOpen)
+
+	// one call is let through after the cooldown, its failure opens the breaker again
+	now = now.Add(time.Minute)
+	assert.Equal(t, call(true), nil)
+	assert.Equal(t, call(false), ErrOpen)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, b.Allow(), nil)
+	assert.Equal(t, b.Allow(), ErrOpen)
+	b.Done(false)
+	assert.Equal(t, b.Open(), false)
+	assert.Equal(t, call(false), nil)
+}
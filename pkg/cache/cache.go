@@ -0,0 +1,15 @@
+// Package cache has the backends of the post cache, a bounded in-process LRU and a shared Redis.
+package cache
+
+import "errors"
+
+// ErrMiss is returned by Get for the keys that are not cached or have expired.
+var ErrMiss = errors.New("cache miss")
+
+// Stats describes the content of a backend.
+type Stats struct {
+	// Size is the number of the cached values
+	Size int64
+	// Evictions counts the values dropped to make room for the new ones
+	Evictions uint64
+}
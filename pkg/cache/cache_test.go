@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+type backend interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+	Stats(ctx context.Context) (Stats, error)
+}
+
+// testBackend runs the behaviour every backend shares, elapse moves the clock of the backend forward.
+func testBackend(t *testing.T, c backend, elapse func(time.Duration)) {
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx, "post:1"); err != ErrMiss {
+		t.Fatalf("get missing key: %v", err)
+	}
+	if err := c.Set(ctx, "post:1", []byte(`{"id":1}`), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "post:2", []byte(`{"id":2}`), 0); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Get(ctx, "post:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(got), `{"id":1}`)
+
+	// setting the key again replaces the value
+	if err := c.Set(ctx, "post:1", []byte(`{"id":1,"title":"new"}`), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	got, err = c.Get(ctx, "post:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(got), `{"id":1,"title":"new"}`)
+
+	elapse(2 * time.Minute)
+	if _, err := c.Get(ctx, "post:1"); err != ErrMiss {
+		t.Fatalf("get expired key: %v", err)
+	}
+	if _, err := c.Get(ctx, "post:2"); err != nil {
+		t.Fatalf("get key without ttl: %v", err)
+	}
+
+	if err := c.Delete(ctx, "post:2", "post:3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "post:2"); err != ErrMiss {
+		t.Fatalf("get deleted key: %v", err)
+	}
+	if err := c.Delete(ctx); err != nil {
+		t.Fatalf("delete no keys: %v", err)
+	}
+
+	for _, key := range []string{"post:1", "post:2", "posts:1", "post*:1"} {
+		if err := c.Set(ctx, key, []byte(key), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 250; i++ {
+		if err := c.Set(ctx, fmt.Sprintf("comment:%d", i), []byte("comment"), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats, err := c.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, stats.Size, int64(254))
+
+	// the prefix is literal, glob characters included
+	for prefix, want := range map[string]int{"post:": 2, "post*": 1, "comment:": 250, "missing:": 0} {
+		deleted, err := c.DeletePrefix(ctx, prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, deleted, want, prefix)
+	}
+	if _, err := c.Get(ctx, "posts:1"); err != nil {
+		t.Fatalf("get key with other prefix: %v", err)
+	}
+	stats, err = c.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, stats.Size, int64(1))
+}
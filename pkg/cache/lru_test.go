@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestLRU(t *testing.T) {
+	c := NewLRU(1000)
+	now := time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	testBackend(t, c, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestLRUEviction(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+	for _, key := range []string{"post:1", "post:2"} {
+		if err := c.Set(ctx, key, []byte(key), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// post:1 is used, so post:2 becomes the least recently used one
+	if _, err := c.Get(ctx, "post:1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "post:3", []byte("post:3"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, c.Len(), 2)
+	stats, err := c.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, stats.Evictions, uint64(1))
+	if _, err := c.Get(ctx, "post:2"); err != ErrMiss {
+		t.Fatalf("get evicted key: %v", err)
+	}
+	for _, key := range []string{"post:1", "post:3"} {
+		if _, err := c.Get(ctx, key); err != nil {
+			t.Fatalf("get %s: %v", key, err)
+		}
+	}
+}
+
+func TestLRUPurge(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+	for _, key := range []string{"post:1", "post:2"} {
+		if err := c.Set(ctx, key, []byte(key), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Purge(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, c.Len(), 0)
+	if _, err := c.Get(ctx, "post:1"); err != ErrMiss {
+		t.Fatalf("get purged key: %v", err)
+	}
+	if err := c.Set(ctx, "post:3", []byte("post:3"), 0); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, c.Len(), 1)
+}
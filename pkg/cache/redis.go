@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis shares the cached values between the replicas, the values expire in Redis itself.
+type Redis struct {
+	client redis.UniversalClient
+}
+
+func NewRedis(client redis.UniversalClient) *Redis {
+	return &Redis{client: client}
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrMiss
+	}
+
+	return value, err
+}
+
+// Set stores the value for ttl, zero ttl keeps it until it is deleted or evicted by the maxmemory policy.
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// DeletePrefix deletes the values with the keys starting with prefix and returns how many of them there were.
+// The keys are scanned in batches, the values set meanwhile may be left.
+func (c *Redis) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	deleted := 0
+	iter := c.client.Scan(ctx, 0, globEscaper.Replace(prefix)+"*", scanBatch).Iterator()
+	keys := make([]string, 0, scanBatch)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) < scanBatch {
+			continue
+		}
+		n, err := c.client.Del(ctx, keys...).Result()
+		if err != nil {
+			return deleted, err
+		}
+		deleted, keys = deleted+int(n), keys[:0]
+	}
+	if err := iter.Err(); err != nil || len(keys) == 0 {
+		return deleted, err
+	}
+
+	n, err := c.client.Del(ctx, keys...).Result()
+	return deleted + int(n), err
+}
+
+// Stats has the number of the keys in the database of the client, so it should keep only the cache.
+// Redis evicts by its maxmemory policy, the evictions are its evicted_keys of INFO, counted for the whole server
+// since it started.
+func (c *Redis) Stats(ctx context.Context) (Stats, error) {
+	size, err := c.client.DBSize(ctx).Result()
+	if err != nil {
+		return Stats{}, err
+	}
+
+	// the default sections of INFO have stats, the servers that do not report evictions count none
+	info, err := c.client.Info(ctx).Result()
+	if err != nil {
+		return Stats{}, err
+	}
+	evictions, err := infoCounter(info, "evicted_keys")
+	return Stats{Size: size, Evictions: evictions}, err
+}
+
+// infoCounter reads the field of the INFO reply, zero when it is missing.
+func infoCounter(info, field string) (uint64, error) {
+	for _, line := range strings.Split(info, "\n") {
+		name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok && name == field {
+			return strconv.ParseUint(value, 10, 64)
+		}
+	}
+
+	return 0, nil
+}
+
+// scanBatch is the number of the keys scanned and deleted at once.
+const scanBatch = 100
+
+// globEscaper makes the special characters of the glob patterns of SCAN MATCH literal.
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
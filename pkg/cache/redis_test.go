@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/magiconair/properties/assert"
+)
+
+func TestRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	testBackend(t, NewRedis(client), server.FastForward)
+}
+
+func TestInfoCounter(t *testing.T) {
+	info := "# Server\r\nredis_version:7.0.5\r\n\r\n# Stats\r\ntotal_connections_received:12\r\n" +
+		"expired_keys:3\r\nevicted_keys:42\r\nevicted_clients:0\r\n"
+
+	evictions, err := infoCounter(info, "evicted_keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, evictions, uint64(42))
+
+	evictions, err = infoCounter("# Clients\r\nconnected_clients:1\r\n", "evicted_keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, evictions, uint64(0))
+}
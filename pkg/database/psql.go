@@ -3,7 +3,9 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"github.com/lib/pq"
 	log "github.com/sirupsen/logrus"
+	"time"
 )
 
 type ConnectionInfo struct {
@@ -16,8 +18,7 @@ type ConnectionInfo struct {
 }
 
 func NewPostgresConnection(info ConnectionInfo) (*sql.DB, error) {
-	connectString := fmt.Sprintf("host=%s port=%d user=%s dbname=%s sslmode=%s password=%s",
-		info.Host, info.Port, info.Username, info.DBName, info.SSLMode, info.Password)
+	connectString := info.connectString()
 	log.Println(connectString)
 	db, err := sql.Open("postgres", connectString)
 
@@ -31,3 +32,20 @@ func NewPostgresConnection(info ConnectionInfo) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// NewListener opens the connection for LISTEN. A lost connection is opened again, the attempts wait minReconnect
+// at first and twice as long after every failure up to maxReconnect.
+func NewListener(info ConnectionInfo, minReconnect, maxReconnect time.Duration) *pq.Listener {
+	return pq.NewListener(info.connectString(), minReconnect, maxReconnect, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.WithFields(log.Fields{
+				"listener": event,
+			}).Error("listener connection failed:", err)
+		}
+	})
+}
+
+func (info ConnectionInfo) connectString() string {
+	return fmt.Sprintf("host=%s port=%d user=%s dbname=%s sslmode=%s password=%s",
+		info.Host, info.Port, info.Username, info.DBName, info.SSLMode, info.Password)
+}
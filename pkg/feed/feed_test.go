@@ -0,0 +1,80 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+var testFeed = Feed{
+	Title:       "Blog",
+	Link:        "http://localhost:8080/",
+	Self:        "http://localhost:8080/feed.atom",
+	Description: "Latest posts",
+	Updated:     time.Date(2022, 10, 13, 9, 0, 0, 0, time.UTC),
+	Items: []Item{{
+		Id:        "tag:localhost,2022-10-12:post-1",
+		Title:     "Go & Postgres",
+		Link:      "http://localhost:8080/posts/go-and-postgres",
+		Author:    "NewGopher",
+		Summary:   "Hello",
+		Content:   "<p>Hello</p>",
+		Published: time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC),
+		Updated:   time.Date(2022, 10, 13, 9, 0, 0, 0, time.UTC),
+	}},
+}
+
+func TestRSS(t *testing.T) {
+	body, err := RSS(testFeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(body)
+
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<rss version="2.0"`,
+		`<atom:link href="http://localhost:8080/feed.atom" rel="self" type="application/rss+xml"></atom:link>`,
+		`<lastBuildDate>Thu, 13 Oct 2022 09:00:00 +0000</lastBuildDate>`,
+		`<title>Go &amp; Postgres</title>`,
+		`<guid isPermaLink="false">tag:localhost,2022-10-12:post-1</guid>`,
+		`<dc:creator>NewGopher</dc:creator>`,
+		`<content:encoded>&lt;p&gt;Hello&lt;/p&gt;</content:encoded>`,
+		`<pubDate>Wed, 12 Oct 2022 15:21:56 +0000</pubDate>`,
+	} {
+		assert.Equal(t, strings.Contains(doc, want), true, want)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	body, err := Atom(testFeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(body)
+
+	for _, want := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		`<id>http://localhost:8080/feed.atom</id>`,
+		`<updated>2022-10-13T09:00:00Z</updated>`,
+		`<id>tag:localhost,2022-10-12:post-1</id>`,
+		`<link href="http://localhost:8080/posts/go-and-postgres" rel="alternate" type="text/html"></link>`,
+		`<author>`,
+		`<name>NewGopher</name>`,
+		`<published>2022-10-12T15:21:56Z</published>`,
+		`<content type="html">&lt;p&gt;Hello&lt;/p&gt;</content>`,
+	} {
+		assert.Equal(t, strings.Contains(doc, want), true, want)
+	}
+}
+
+func TestAtomEmpty(t *testing.T) {
+	body, err := Atom(Feed{Title: "Blog", Link: "http://localhost:8080/", Self: "http://localhost:8080/feed.atom"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, strings.Contains(string(body), "<updated>1970-01-01T00:00:00Z</updated>"), true)
+}
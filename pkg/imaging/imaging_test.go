@@ -0,0 +1,141 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+// withOrientation inserts an EXIF segment with the orientation tag right after the SOI marker of the JPEG.
+func withOrientation(t *testing.T, jpg []byte, orientation uint16) []byte {
+	t.Helper()
+	tiff := make([]byte, 8+2+12+4)
+	copy(tiff, "MM")
+	binary.BigEndian.PutUint16(tiff[2:], 42)
+	binary.BigEndian.PutUint32(tiff[4:], 8)
+	binary.BigEndian.PutUint16(tiff[8:], 1)
+	binary.BigEndian.PutUint16(tiff[10:], 0x0112)
+	binary.BigEndian.PutUint16(tiff[12:], 3)
+	binary.BigEndian.PutUint32(tiff[14:], 1)
+	binary.BigEndian.PutUint16(tiff[18:], orientation)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, app1...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+// landscape is 40x20 with a red left half and a blue right half.
+func landscape() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.NRGBA{R: 0xff, A: 0xff}
+			if x >= 20 {
+				c = color.NRGBA{B: 0xff, A: 0xff}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestDecodeOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, landscape(), &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		orientation uint16
+		size        image.Point
+		// redAt is a point well inside the red half after orienting
+		redAt image.Point
+	}{
+		{name: "Normal", orientation: 1, size: image.Pt(40, 20), redAt: image.Pt(5, 10)},
+		{name: "Mirrored", orientation: 2, size: image.Pt(40, 20), redAt: image.Pt(35, 10)},
+		{name: "Rotated 180", orientation: 3, size: image.Pt(40, 20), redAt: image.Pt(35, 10)},
+		{name: "Rotated 90 CW", orientation: 6, size: image.Pt(20, 40), redAt: image.Pt(10, 5)},
+		{name: "Rotated 90 CCW", orientation: 8, size: image.Pt(20, 40), redAt: image.Pt(10, 35)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := withOrientation(t, buf.Bytes(), test.orientation)
+			assert.Equal(t, Orientation(data), int(test.orientation))
+
+			img, err := Decode(bytes.NewReader(data), 1<<20)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, img.Bounds().Size(), test.size)
+			c := img.NRGBAAt(test.redAt.X, test.redAt.Y)
+			if c.R < 0xc0 || c.B > 0x40 {
+				t.Fatalf("expected red at %v, got %v", test.redAt, c)
+			}
+		})
+	}
+}
+
+func TestDecodeTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, landscape()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Decode(&buf, 40*20-1)
+	assert.Equal(t, err, ErrTooLarge)
+}
+
+func TestFit(t *testing.T) {
+	img := landscape()
+
+	assert.Equal(t, Fit(img, 10).Bounds(), image.Rect(0, 0, 10, 5))
+	assert.Equal(t, Fit(img, 3).Bounds(), image.Rect(0, 0, 3, 2))
+	// images are never scaled up
+	assert.Equal(t, Fit(img, 80), img)
+}
+
+func TestEncodeJPEGDropsMetadata(t *testing.T) {
+	var src bytes.Buffer
+	if err := jpeg.Encode(&src, landscape(), nil); err != nil {
+		t.Fatal(err)
+	}
+	img, err := Decode(bytes.NewReader(withOrientation(t, src.Bytes(), 6)), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := EncodeJPEG(&out, img, 80); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, bytes.Contains(out.Bytes(), []byte("Exif")), false)
+	assert.Equal(t, Orientation(out.Bytes()), 1)
+}
+
+func TestEncodeJPEGFlattensOntoWhite(t *testing.T) {
+	var out bytes.Buffer
+	if err := EncodeJPEG(&out, image.NewNRGBA(image.Rect(0, 0, 8, 8)), 100); err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, g, b, _ := img.At(4, 4).RGBA()
+	if r>>8 < 0xf0 || g>>8 < 0xf0 || b>>8 < 0xf0 {
+		t.Fatalf("expected white, got %v", img.At(4, 4))
+	}
+}
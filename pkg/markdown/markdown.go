@@ -0,0 +1,82 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+const (
+	// ExcerptLength is the maximum number of characters in the post excerpt.
+	ExcerptLength = 200
+	// WordsPerMinute is the average reading speed used for the reading time estimate.
+	WordsPerMinute = 200
+)
+
+var (
+	md = goldmark.New(goldmark.WithExtensions(extension.Table, extension.Strikethrough))
+
+	ugc   = newUGCPolicy()
+	plain = bluemonday.StrictPolicy()
+
+	spaces      = regexp.MustCompile(`\s+`)
+	blockBreaks = regexp.MustCompile(`(?i)</(p|h[1-6]|li|pre|blockquote|t[dh])>|<br\s*/?>|<hr\s*/?>`)
+)
+
+func newUGCPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// keep the language of fenced code blocks for client side highlighting
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	return p
+}
+
+// Render converts CommonMark with GFM tables to HTML that is safe to embed into a page.
+func Render(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+
+	return ugc.Sanitize(buf.String()), nil
+}
+
+// PlainText strips the tags from the rendered post and collapses the whitespace.
+func PlainText(renderedHTML string) string {
+	// block elements are glued together once the tags are stripped
+	text := blockBreaks.ReplaceAllString(renderedHTML, " $0")
+	text = html.UnescapeString(plain.Sanitize(text))
+	return strings.TrimSpace(spaces.ReplaceAllString(text, " "))
+}
+
+// Excerpt returns the beginning of the rendered post as plain text,
+// cut on a word boundary and not longer than ExcerptLength characters.
+func Excerpt(renderedHTML string) string {
+	text := PlainText(renderedHTML)
+
+	if utf8.RuneCountInString(text) <= ExcerptLength {
+		return text
+	}
+
+	runes := []rune(text)[:ExcerptLength]
+	if i := strings.LastIndex(string(runes), " "); i > 0 {
+		return strings.TrimRight(string(runes)[:i], " .,;:-") + "…"
+	}
+
+	return string(runes) + "…"
+}
+
+// WordCount counts the words of the rendered post, markup is not counted.
+func WordCount(renderedHTML string) int {
+	return len(strings.Fields(PlainText(renderedHTML)))
+}
+
+// ReadingTime estimates the reading time in minutes, rounded up.
+func ReadingTime(words int) int {
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
@@ -0,0 +1,93 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "Paragraph",
+			source: "Hello, **gopher**!",
+			want:   "<p>Hello, <strong>gopher</strong>!</p>\n",
+		},
+		{
+			name:   "Code fence",
+			source: "```go\nfmt.Println(\"hi\")\n```",
+			want:   "<pre><code class=\"language-go\">fmt.Println(&#34;hi&#34;)\n</code></pre>\n",
+		},
+		{
+			name:   "Table",
+			source: "| a | b |\n|---|---|\n| 1 | 2 |",
+			want:   "<table>\n<thead>\n<tr>\n<th>a</th>\n<th>b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n<td>2</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		{
+			name:   "Raw script",
+			source: "<script>alert(1)</script>\n\ntext",
+			want:   "\n<p>text</p>\n",
+		},
+		{
+			name:   "Javascript link",
+			source: "[click](javascript:alert(1))",
+			want:   "<p>click</p>\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Render(test.source)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, got, test.want)
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "Short",
+			html: "<h1>Title</h1>\n<p>Tom &amp; Jerry</p>\n",
+			want: "Title Tom & Jerry",
+		},
+		{
+			name: "Long",
+			html: "<p>" + strings.Repeat("word ", 60) + "</p>",
+			want: strings.TrimSpace(strings.Repeat("word ", 40)) + "…",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, Excerpt(test.html), test.want)
+		})
+	}
+}
+
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		name        string
+		html        string
+		words       int
+		readingTime int
+	}{
+		{name: "Empty", html: "", words: 0, readingTime: 0},
+		{name: "Markup is not counted", html: "<h1>Title</h1>\n<p>Hello, <strong>gopher</strong>!</p>", words: 3, readingTime: 1},
+		{name: "Long", html: "<p>" + strings.Repeat("word ", 401) + "</p>", words: 401, readingTime: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			words := WordCount(test.html)
+			assert.Equal(t, words, test.words)
+			assert.Equal(t, ReadingTime(words), test.readingTime)
+		})
+	}
+}
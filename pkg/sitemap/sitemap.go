@@ -0,0 +1,77 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// MaxURLs is the limit of urls in a single sitemap, bigger sites are split and listed by an index.
+const MaxURLs = 50000
+
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type entry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Writer streams the entries of a sitemap or a sitemap index, so they never have to be kept in memory together.
+type Writer struct {
+	enc  *xml.Encoder
+	root xml.StartElement
+	item string
+}
+
+// NewURLSet starts a sitemap with the urls of the pages.
+func NewURLSet(w io.Writer) (*Writer, error) {
+	return newWriter(w, "urlset", "url")
+}
+
+// NewIndex starts a sitemap index with the urls of the sitemaps.
+func NewIndex(w io.Writer) (*Writer, error) {
+	return newWriter(w, "sitemapindex", "sitemap")
+}
+
+func newWriter(w io.Writer, root, item string) (*Writer, error) {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return nil, err
+	}
+
+	sw := &Writer{
+		enc: xml.NewEncoder(w),
+		root: xml.StartElement{
+			Name: xml.Name{Local: root},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: xmlns}},
+		},
+		item: item,
+	}
+	if err := sw.enc.EncodeToken(sw.root); err != nil {
+		return nil, err
+	}
+
+	return sw, nil
+}
+
+func (w *Writer) Add(u URL) error {
+	e := entry{Loc: u.Loc}
+	if !u.LastMod.IsZero() {
+		e.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+	}
+
+	return w.enc.EncodeElement(e, xml.StartElement{Name: xml.Name{Local: w.item}})
+}
+
+// Close ends the document, it does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.enc.EncodeToken(w.root.End()); err != nil {
+		return err
+	}
+
+	return w.enc.Flush()
+}
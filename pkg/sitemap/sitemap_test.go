@@ -0,0 +1,53 @@
+package sitemap
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestURLSet(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewURLSet(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []URL{
+		{Loc: "http://localhost:8080/posts/go", LastMod: time.Date(2022, 10, 12, 15, 21, 56, 0, time.UTC)},
+		{Loc: "http://localhost:8080/posts/a&b"},
+	} {
+		if err := w.Add(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, buf.String(), `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`+
+		`<url><loc>http://localhost:8080/posts/go</loc><lastmod>2022-10-12T15:21:56Z</lastmod></url>`+
+		`<url><loc>http://localhost:8080/posts/a&amp;b</loc></url>`+
+		`</urlset>`)
+}
+
+func TestIndex(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewIndex(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(URL{Loc: "http://localhost:8080/sitemap/1.xml", LastMod: time.Date(2022, 10, 12, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, buf.String(), `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`+
+		`<sitemap><loc>http://localhost:8080/sitemap/1.xml</loc><lastmod>2022-10-12T00:00:00Z</lastmod></sitemap>`+
+		`</sitemapindex>`)
+}
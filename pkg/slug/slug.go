@@ -0,0 +1,85 @@
+package slug
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength limits the generated slug, collision suffixes are added on top of it.
+const MaxLength = 100
+
+const fallback = "post"
+
+// translit maps letters that do not decompose into Latin ones.
+var translit = map[rune]string{
+	// russian
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+	// ukrainian and belarusian
+	'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ў': "u",
+	// greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o",
+	// latin letters without decomposition
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th",
+	'&': "and",
+}
+
+// Make builds a lowercase URL-safe slug from the given title.
+// Non-Latin letters are transliterated, everything else that is not
+// a latin letter or a digit becomes a single dash.
+func Make(title string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(title) {
+		part, ok := translit[r]
+		if ok && part == "" {
+			// hard and soft signs are dropped without a separator
+			continue
+		}
+		if !ok {
+			part = latin(r)
+		}
+
+		if part == "" {
+			dash = b.Len() > 0
+			continue
+		}
+
+		if dash {
+			b.WriteByte('-')
+			dash = false
+		}
+		b.WriteString(part)
+	}
+
+	s := b.String()
+	if len(s) > MaxLength {
+		s = strings.TrimRight(s[:MaxLength], "-")
+	}
+	if s == "" {
+		return fallback
+	}
+
+	return s
+}
+
+// latin strips diacritics from r and keeps only ASCII letters and digits.
+func latin(r rune) string {
+	var b strings.Builder
+	for _, d := range norm.NFKD.String(string(r)) {
+		if d < unicode.MaxASCII && (unicode.IsLetter(d) || unicode.IsDigit(d)) {
+			b.WriteRune(unicode.ToLower(d))
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,34 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{name: "Latin", title: "Web Development in Go", want: "web-development-in-go"},
+		{name: "Punctuation", title: "  Hello, World!!  ", want: "hello-world"},
+		{name: "Diacritics", title: "Crème brûlée à la française", want: "creme-brulee-a-la-francaise"},
+		{name: "Cyrillic", title: "Привет, мир", want: "privet-mir"},
+		{name: "Soft sign", title: "Объект и сеть", want: "obekt-i-set"},
+		{name: "Short i and yo", title: "Йошкар-Ола, ёлка", want: "yoshkar-ola-yolka"},
+		{name: "Ukrainian", title: "Їжак і ґанок", want: "yizhak-i-ganok"},
+		{name: "Mixed", title: "Go и Postgres: 10 советов", want: "go-i-postgres-10-sovetov"},
+		{name: "Ligatures", title: "Straße & Œuvre", want: "strasse-and-oeuvre"},
+		{name: "Only symbols", title: "!!! ???", want: "post"},
+		{name: "Empty", title: "", want: "post"},
+		{name: "Too long", title: strings.Repeat("a", MaxLength-1) + " bc", want: strings.Repeat("a", MaxLength-1)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, Make(test.title), test.want)
+		})
+	}
+}
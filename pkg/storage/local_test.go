@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestLocal(t *testing.T) {
+	s, err := NewLocal(filepath.Join(t.TempDir(), "media"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testStorage(t, s)
+}
+
+func TestLocalKeepsObjectsInRoot(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewLocal(filepath.Join(root, "media"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Put(context.Background(), "../../escaped", strings.NewReader("x"), 1, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = os.Stat(filepath.Join(root, "media", "escaped"))
+	assert.Equal(t, err, nil)
+}
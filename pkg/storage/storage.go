@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Storage keeps immutable objects under content-addressed keys, see Key.
+type Storage interface {
+	// Put stores the object, putting an existing key again keeps the stored object.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Open returns ErrNotFound when there is no object under the key.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object, deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Key is the storage key of the content with the given hex digest,
+// the first bytes of the digest spread the objects over directories.
+func Key(digest string) string {
+	if len(digest) < 4 {
+		return digest
+	}
+
+	return digest[:2] + "/" + digest[2:4] + "/" + digest
+}
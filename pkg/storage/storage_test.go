@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+// testStorage runs the behaviour every backend shares.
+func testStorage(t *testing.T, s Storage) {
+	ctx := context.Background()
+	key := Key("0a1b2c3d4e5f")
+
+	if _, err := s.Open(ctx, key); err != ErrNotFound {
+		t.Fatalf("open missing object: %v", err)
+	}
+	if err := s.Put(ctx, key, strings.NewReader("gopher"), 6, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	// the same content is put again by another upload
+	if err := s.Put(ctx, key, strings.NewReader("gopher"), 6, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := s.Open(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(got), "gopher")
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open(ctx, key); err != ErrNotFound {
+		t.Fatalf("open deleted object: %v", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("delete missing object: %v", err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key("0a1b2c3d4e5f"), "0a/1b/0a1b2c3d4e5f")
+	assert.Equal(t, Key("0a1"), "0a1")
+}
@@ -0,0 +1,6 @@
+DROP TABLE post_slugs;
+
+DROP INDEX IF EXISTS posts_slug_idx;
+
+ALTER TABLE posts
+    DROP COLUMN slug;
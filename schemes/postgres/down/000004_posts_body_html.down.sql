@@ -0,0 +1,3 @@
+ALTER TABLE posts
+    DROP COLUMN excerpt,
+    DROP COLUMN body_html;
@@ -0,0 +1,4 @@
+ALTER TABLE posts
+    DROP COLUMN reading_time,
+    DROP COLUMN word_count,
+    ALTER COLUMN body TYPE varchar(255) USING left(body, 255);
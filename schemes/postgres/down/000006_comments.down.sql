@@ -0,0 +1,4 @@
+ALTER TABLE posts
+    DROP COLUMN comments_count;
+
+DROP TABLE comments;
@@ -0,0 +1,4 @@
+ALTER TABLE posts
+    DROP COLUMN reactions;
+
+DROP TABLE post_reactions;
@@ -0,0 +1,7 @@
+DROP INDEX posts_feed_idx;
+
+ALTER TABLE users
+    DROP COLUMN followers_count,
+    DROP COLUMN following_count;
+
+DROP TABLE follows;
@@ -0,0 +1,2 @@
+DROP TABLE post_media;
+DROP TABLE media;
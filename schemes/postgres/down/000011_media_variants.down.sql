@@ -0,0 +1,6 @@
+DROP INDEX media_unprocessed_idx;
+
+ALTER TABLE media
+    DROP COLUMN processed_at;
+
+DROP TABLE media_variants;
@@ -0,0 +1 @@
+DROP INDEX audit_outbox_delivered_idx;
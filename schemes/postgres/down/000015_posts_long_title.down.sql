@@ -0,0 +1,2 @@
+ALTER TABLE posts
+    ALTER COLUMN title TYPE varchar(255) USING left(title, 255);
@@ -0,0 +1,4 @@
+ALTER TABLE posts
+    ADD COLUMN deleted_at timestamp default null;
+
+CREATE INDEX posts_deleted_at_idx ON posts (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,18 @@
+ALTER TABLE posts
+    ADD COLUMN slug varchar(255);
+
+UPDATE posts SET slug = 'post-' || id WHERE slug IS NULL;
+
+ALTER TABLE posts
+    ALTER COLUMN slug SET NOT NULL;
+
+CREATE UNIQUE INDEX posts_slug_idx ON posts (slug);
+
+CREATE TABLE post_slugs
+(
+    slug      varchar(255) not null primary key,
+    post_id   integer      not null references posts (id) on delete cascade,
+    createdAt timestamp    not null default now()
+);
+
+CREATE INDEX post_slugs_post_id_idx ON post_slugs (post_id);
@@ -0,0 +1,3 @@
+ALTER TABLE posts
+    ADD COLUMN body_html text not null default '',
+    ADD COLUMN excerpt   text not null default '';
@@ -0,0 +1,12 @@
+ALTER TABLE posts
+    ALTER COLUMN body TYPE text,
+    ADD COLUMN word_count   integer not null default 0,
+    ADD COLUMN reading_time integer not null default 0;
+
+UPDATE posts
+SET word_count = coalesce(array_length(regexp_split_to_array(trim(body), '\s+'), 1), 0)
+WHERE trim(body) <> '';
+
+UPDATE posts
+SET reading_time = ceil(word_count / 200.0)
+WHERE word_count > 0;
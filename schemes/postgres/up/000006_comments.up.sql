@@ -0,0 +1,17 @@
+CREATE TABLE comments
+(
+    id         serial    not null primary key,
+    post_id    integer   not null references posts (id) on delete cascade,
+    parent_id  integer references comments (id) on delete cascade,
+    author_id  integer   not null references users (id),
+    body       text      not null,
+    createdAt  timestamp not null default now(),
+    updatedAt  timestamp not null default now(),
+    deleted_at timestamp default null
+);
+
+CREATE INDEX comments_post_id_idx ON comments (post_id, id) WHERE parent_id IS NULL;
+CREATE INDEX comments_parent_id_idx ON comments (parent_id);
+
+ALTER TABLE posts
+    ADD COLUMN comments_count integer not null default 0;
@@ -0,0 +1,13 @@
+CREATE TABLE post_reactions
+(
+    post_id   integer     not null references posts (id) on delete cascade,
+    user_id   integer     not null references users (id),
+    kind      varchar(16) not null,
+    createdAt timestamp   not null default now(),
+    primary key (post_id, user_id, kind)
+);
+
+CREATE INDEX post_reactions_user_id_idx ON post_reactions (user_id, post_id);
+
+ALTER TABLE posts
+    ADD COLUMN reactions jsonb not null default '{}';
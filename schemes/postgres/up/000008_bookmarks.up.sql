@@ -0,0 +1,11 @@
+CREATE TABLE bookmarks
+(
+    user_id   integer     not null references users (id),
+    list      varchar(64) not null default 'default',
+    post_id   integer     not null references posts (id) on delete cascade,
+    createdAt timestamp   not null default now(),
+    primary key (user_id, list, post_id)
+);
+
+CREATE INDEX bookmarks_list_idx ON bookmarks (user_id, list, createdAt DESC, post_id DESC);
+CREATE INDEX bookmarks_post_id_idx ON bookmarks (user_id, post_id);
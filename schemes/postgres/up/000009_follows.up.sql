@@ -0,0 +1,17 @@
+CREATE TABLE follows
+(
+    follower_id integer   not null references users (id),
+    author_id   integer   not null references users (id),
+    createdAt   timestamp not null default now(),
+    primary key (follower_id, author_id),
+    check (follower_id <> author_id)
+);
+
+CREATE INDEX follows_author_id_idx ON follows (author_id);
+
+ALTER TABLE users
+    ADD COLUMN followers_count integer not null default 0,
+    ADD COLUMN following_count integer not null default 0;
+
+-- the feed reads up to a page of the newest posts of each followed author from this index, one scan per author
+CREATE INDEX posts_feed_idx ON posts (author_id, createdAt DESC, id DESC) WHERE deleted_at IS NULL;
@@ -0,0 +1,21 @@
+CREATE TABLE media
+(
+    id        serial      not null primary key,
+    owner_id  integer     not null references users (id),
+    hash      char(64)    not null,
+    mime_type varchar(64) not null,
+    size      bigint      not null,
+    createdAt timestamp   not null default now()
+);
+
+CREATE INDEX media_hash_idx ON media (hash);
+
+CREATE TABLE post_media
+(
+    post_id   integer   not null references posts (id) on delete cascade,
+    media_id  integer   not null references media (id),
+    createdAt timestamp not null default now(),
+    primary key (post_id, media_id)
+);
+
+CREATE INDEX post_media_media_id_idx ON post_media (media_id);
@@ -0,0 +1,18 @@
+CREATE TABLE media_variants
+(
+    media_id  integer     not null references media (id),
+    name      varchar(32) not null,
+    mime_type varchar(64) not null,
+    width     integer     not null,
+    height    integer     not null,
+    hash      char(64)    not null,
+    size      bigint      not null,
+    primary key (media_id, name, mime_type)
+);
+
+CREATE INDEX media_variants_hash_idx ON media_variants (hash);
+
+ALTER TABLE media
+    ADD COLUMN processed_at timestamp default null;
+
+CREATE INDEX media_unprocessed_idx ON media (id) WHERE processed_at IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE posts
+    ADD COLUMN version integer not null default 1;
@@ -0,0 +1,14 @@
+CREATE TABLE audit_outbox
+(
+    id           bigserial   not null primary key,
+    key          uuid        not null default gen_random_uuid() unique,
+    entity       varchar(16) not null,
+    action       varchar(16) not null,
+    entity_id    bigint      not null,
+    user_id      bigint      not null,
+    timestamp    timestamp   not null,
+    createdAt    timestamp   not null default now(),
+    delivered_at timestamp
+);
+
+CREATE INDEX audit_outbox_pending_idx ON audit_outbox (id) WHERE delivered_at IS NULL;
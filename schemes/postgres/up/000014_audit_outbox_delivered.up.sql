@@ -0,0 +1 @@
+CREATE INDEX audit_outbox_delivered_idx ON audit_outbox (delivered_at) WHERE delivered_at IS NOT NULL;